@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(catalogCmd)
+
+	addVerifyFlags(catalogCmd)
+}
+
+var catalogCmd = &cobra.Command{
+	Use:   "catalog",
+	Short: "Validate every operator manifest in a catalog directory.",
+	Long:  `Validates each operator manifest directory under the catalog root, then checks the packages against each other for duplicate package names, APIs owned by more than one package and required APIs that no package provides. Prints a summary per package. Takes in one argument i.e. path to the catalog root.`,
+	Run:   catalogFunc,
+}
+
+func catalogFunc(cmd *cobra.Command, args []string) {
+
+	if len(args) != 1 {
+		fmt.Printf("command %s requires exactly one argument\n", cmd.CommandPath())
+		return
+	}
+
+	if err := completeVerifyOptions(); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	summaries, catalogResult := validate.ValidateCatalog(args[0], verifyOptions)
+
+	fmt.Printf("\n\nValidating `%s` catalog\n\n", args[0])
+	for _, err := range catalogResult.Warnings {
+		fmt.Println(err)
+	}
+	for _, err := range catalogResult.Errors {
+		fmt.Println(err)
+	}
+	if len(catalogResult.Errors) == 0 {
+		fmt.Println("No conflicts between packages")
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PACKAGE\tDIRECTORY\tDEFAULT CHANNEL\tCHANNELS\tBUNDLES\tERRORS\tWARNINGS")
+	for _, summary := range summaries {
+		name := summary.Package
+		if name == "" {
+			name = "<unknown>"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%d\n", name, summary.Directory, summary.DefaultChannel, summary.Channels, summary.Bundles, summary.Errors, summary.Warnings)
+	}
+	w.Flush()
+}
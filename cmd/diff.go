@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate"
+	"github.com/spf13/cobra"
+)
+
+var diffOutput string
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVarP(&diffOutput, "output", "o", "text", "output format: text or json")
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare two versions of an operator manifest.",
+	Long:  `Compares two versions of an operator manifest and reports added and removed bundles, channel head moves, default channel changes, changes to the permissions, install modes, images and owned APIs of ClusterServiceVersions, and CustomResourceDefinition schema changes. Takes in two arguments i.e. paths to the old and new manifest directories.`,
+	Run:   diffFunc,
+}
+
+func diffFunc(cmd *cobra.Command, args []string) {
+
+	if len(args) != 2 {
+		fmt.Printf("command %s requires exactly two arguments\n", cmd.CommandPath())
+		return
+	}
+
+	var manifests []validate.Manifest
+	for _, manifestDirectory := range args {
+		manifest, parseResult := validate.ParseDir(manifestDirectory)
+		if len(parseResult.Errors) != 0 {
+			for _, err := range parseResult.Errors {
+				fmt.Println(err)
+			}
+			fmt.Printf("Invalid operator manifest structure for `%s`\n", manifestDirectory)
+			return
+		}
+		manifests = append(manifests, manifest)
+	}
+
+	diff, diffResult := validate.DiffManifests(manifests[0], manifests[1])
+	for _, err := range diffResult.Errors {
+		fmt.Println(err)
+	}
+
+	switch diffOutput {
+	case "json":
+		out, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Println(string(out))
+	case "text":
+		printDiff(diff)
+	default:
+		fmt.Printf("unsupported output format %q; expected text or json\n", diffOutput)
+	}
+}
+
+func printDiff(diff validate.ManifestDiff) {
+	if diff.IsEmpty() {
+		fmt.Println("No changes")
+		return
+	}
+	for _, name := range diff.AddedBundles {
+		fmt.Printf("+ bundle %s\n", name)
+	}
+	for _, name := range diff.RemovedBundles {
+		fmt.Printf("- bundle %s\n", name)
+	}
+	if diff.DefaultChannel != nil {
+		fmt.Printf("~ default channel: %s -> %s\n", diff.DefaultChannel.Old, diff.DefaultChannel.New)
+	}
+	for _, channel := range diff.ChannelHeads {
+		fmt.Printf("~ channel %s head: %s -> %s\n", channel.Channel, channel.Old, channel.New)
+	}
+	for _, csv := range diff.CSVChanges {
+		if csv.Old == csv.New {
+			fmt.Printf("~ csv %s\n", csv.New)
+		} else {
+			fmt.Printf("~ csv %s -> %s\n", csv.Old, csv.New)
+		}
+		for _, change := range csv.Changes {
+			fmt.Printf("    %s\n", change)
+		}
+	}
+	for _, crd := range diff.CRDChanges {
+		fmt.Printf("~ crd %s %s\n", crd.Name, crd.Change)
+		for _, change := range crd.Changes {
+			fmt.Printf("    %s\n", change)
+		}
+		for _, change := range crd.Breaking {
+			fmt.Printf("    breaking: %s\n", change)
+		}
+	}
+}
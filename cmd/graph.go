@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate"
+	"github.com/spf13/cobra"
+)
+
+var graphFormat string
+
+func init() {
+	rootCmd.AddCommand(graphCmd)
+
+	graphCmd.Flags().StringVar(&graphFormat, "format", validate.GraphFormatDOT, "output format: dot, mermaid or json")
+}
+
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Render the upgrade graph of an operator manifest.",
+	Long:  `Renders the upgrade graph of each channel of an operator manifest, built from the replaces, skips and olm.skipRange of its ClusterServiceVersions. Channel heads, the default channel and CSVs that belong to no channel are highlighted. Takes in one argument i.e. path to the manifest directory.`,
+	Run:   graphFunc,
+}
+
+func graphFunc(cmd *cobra.Command, args []string) {
+
+	if len(args) != 1 {
+		fmt.Printf("command %s requires exactly one argument\n", cmd.CommandPath())
+		return
+	}
+
+	manifest, parseResult := validate.ParseDir(args[0])
+	if len(parseResult.Errors) != 0 {
+		for _, err := range parseResult.Errors {
+			fmt.Println(err)
+		}
+		fmt.Printf("Invalid operator manifest structure for `%s`\n", args[0])
+		return
+	}
+
+	graph, graphResult := validate.BuildUpgradeGraph(manifest)
+	for _, err := range graphResult.Errors {
+		fmt.Println(err)
+	}
+
+	out, err := validate.RenderUpgradeGraph(graph, graphFormat)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(out)
+}
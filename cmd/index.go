@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate"
+	"github.com/spf13/cobra"
+)
+
+var indexOutput string
+
+func init() {
+	rootCmd.AddCommand(indexCmd)
+	indexCmd.AddCommand(indexBuildCmd)
+	indexCmd.AddCommand(indexValidateCmd)
+
+	indexBuildCmd.Flags().StringVarP(&indexOutput, "output", "o", "index.db", "file name of the index database to create")
+	addVerifyFlags(indexBuildCmd)
+}
+
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Build and validate operator-registry index databases.",
+	Long:  `Builds operator-registry SQLite index databases from a catalog directory, and validates existing ones.`,
+}
+
+var indexBuildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Build an index database from a catalog directory.",
+	Long:  `Validates every operator manifest under the catalog root as the catalog command does and, if no errors are found, loads the catalog into a new operator-registry SQLite database. Takes in one argument i.e. path to the catalog root.`,
+	Run:   indexBuildFunc,
+}
+
+var indexValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate the packages of an index database.",
+	Long:  `Checks the packages, channels and replaces edges of an operator-registry SQLite database with the rules used for manifest directories. Takes in one argument i.e. path to the database.`,
+	Run:   indexValidateFunc,
+}
+
+func indexBuildFunc(cmd *cobra.Command, args []string) {
+
+	if len(args) != 1 {
+		fmt.Printf("command %s requires exactly one argument\n", cmd.CommandPath())
+		return
+	}
+
+	if err := completeVerifyOptions(); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	result := validate.BuildIndex(args[0], indexOutput, verifyOptions)
+	fmt.Println()
+	for _, err := range result.Errors {
+		fmt.Println(err)
+	}
+	if len(result.Errors) == 0 {
+		fmt.Printf("Built index %s from catalog `%s`\n", indexOutput, args[0])
+	}
+}
+
+func indexValidateFunc(cmd *cobra.Command, args []string) {
+
+	if len(args) != 1 {
+		fmt.Printf("command %s requires exactly one argument\n", cmd.CommandPath())
+		return
+	}
+
+	for _, result := range validate.ValidateIndex(args[0]) {
+		kind := "package"
+		if result.Name == args[0] {
+			kind = "index"
+		}
+		fmt.Printf("\nValidating `%s` %s\n\n", result.Name, kind)
+		for _, err := range result.Warnings {
+			fmt.Println(err)
+		}
+		if len(result.Errors) != 0 {
+			for _, err := range result.Errors {
+				fmt.Println(err)
+			}
+			fmt.Printf("Invalid %s: `%s`\n", kind, result.Name)
+		} else {
+			fmt.Printf("`%s` %s verified\n", result.Name, kind)
+		}
+	}
+}
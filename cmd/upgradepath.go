@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate"
+	"github.com/spf13/cobra"
+)
+
+var (
+	upgradeFrom    string
+	upgradeChannel string
+)
+
+func init() {
+	rootCmd.AddCommand(upgradePathCmd)
+
+	upgradePathCmd.Flags().StringVar(&upgradeFrom, "from", "", "name of the installed ClusterServiceVersion")
+	upgradePathCmd.Flags().StringVar(&upgradeChannel, "channel", "", "channel to upgrade in (defaults to the package's default channel)")
+}
+
+var upgradePathCmd = &cobra.Command{
+	Use:   "upgrade-path",
+	Short: "Show the upgrade path OLM would take from an installed CSV.",
+	Long:  `Resolves the sequence of upgrades OLM would perform from an installed ClusterServiceVersion to the head of a channel, using the replaces, skips and olm.skipRange of the operator manifest. Prints each hop and flags dead ends. Takes in one argument i.e. path to the manifest directory.`,
+	Run:   upgradePathFunc,
+}
+
+func upgradePathFunc(cmd *cobra.Command, args []string) {
+
+	if len(args) != 1 {
+		fmt.Printf("command %s requires exactly one argument\n", cmd.CommandPath())
+		return
+	}
+	if upgradeFrom == "" {
+		fmt.Printf("command %s requires the --from flag\n", cmd.CommandPath())
+		return
+	}
+
+	manifest, parseResult := validate.ParseDir(args[0])
+	if len(parseResult.Errors) != 0 {
+		for _, err := range parseResult.Errors {
+			fmt.Println(err)
+		}
+		fmt.Printf("Invalid operator manifest structure for `%s`\n", args[0])
+		return
+	}
+	graph, graphResult := validate.BuildUpgradeGraph(manifest)
+	for _, err := range graphResult.Errors {
+		fmt.Println(err)
+	}
+
+	path, err := validate.ResolveUpgradePath(graph, upgradeFrom, upgradeChannel)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("Upgrade path from `%s` in channel `%s` (head `%s`):\n", path.From, path.Channel, path.Head)
+	for i, hop := range path.Hops {
+		fmt.Printf("  %d. %s -> %s (%s)\n", i+1, hop.From, hop.To, hop.Kind)
+	}
+	switch {
+	case path.DeadEnd:
+		last := path.From
+		if len(path.Hops) != 0 {
+			last = path.Hops[len(path.Hops)-1].To
+		}
+		fmt.Printf("Dead end: no CSV in channel `%s` upgrades `%s`; the head `%s` cannot be reached\n", path.Channel, last, path.Head)
+	case len(path.Hops) == 0:
+		fmt.Printf("`%s` is already the head of channel `%s`\n", path.From, path.Channel)
+	default:
+		fmt.Printf("Reached channel head `%s` in %d hop(s)\n", path.Head, len(path.Hops))
+	}
+}
@@ -7,8 +7,57 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	verifyOptions = validate.DefaultOptions()
+
+	operatorGroupNamespace   string
+	targetNamespaces         []string
+	schemaSeverity           string
+	structuralSchemaSeverity string
+)
+
 func init() {
 	rootCmd.AddCommand(verifyCmd)
+
+	addVerifyFlags(verifyCmd)
+	addCatalogFlag(verifyCmd)
+}
+
+// addVerifyFlags registers the flags that set verifyOptions on cmd.
+func addVerifyFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&verifyOptions.IconMaxBytes, "icon-max-bytes", verifyOptions.IconMaxBytes, "maximum size of a decoded CSV icon in bytes (0 disables the check)")
+	cmd.Flags().IntVar(&verifyOptions.IconMaxWidth, "icon-max-width", verifyOptions.IconMaxWidth, "maximum width of a CSV icon in pixels (0 disables the check)")
+	cmd.Flags().IntVar(&verifyOptions.IconMaxHeight, "icon-max-height", verifyOptions.IconMaxHeight, "maximum height of a CSV icon in pixels (0 disables the check)")
+	cmd.Flags().BoolVar(&verifyOptions.RequireDigests, "require-digests", verifyOptions.RequireDigests, "require every image referenced by a CSV to be pinned by digest and listed in spec.relatedImages")
+	cmd.Flags().StringVar(&schemaSeverity, "schema-severity", string(verifyOptions.SchemaSeverity), "severity of CRD openAPIV3Schema findings: error, warning or ignore")
+	cmd.Flags().StringVar(&structuralSchemaSeverity, "structural-schema-severity", string(verifyOptions.StructuralSchemaSeverity), "severity of non-structural CRD schema findings: error, warning or ignore")
+	cmd.Flags().StringVar(&operatorGroupNamespace, "operatorgroup-namespace", "", "simulate installing each CSV in an OperatorGroup in this namespace")
+	cmd.Flags().StringSliceVar(&targetNamespaces, "target-namespaces", nil, "target namespaces of the simulated OperatorGroup (empty targets all namespaces)")
+}
+
+// addCatalogFlag registers the flag that sets verifyOptions.CatalogDir on cmd.
+func addCatalogFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&verifyOptions.CatalogDir, "catalog", "", "directory of other operators' manifests used to check that required APIs are provided")
+}
+
+// completeVerifyOptions sets the fields of verifyOptions that are parsed from
+// their flags.
+func completeVerifyOptions() error {
+	var err error
+	if verifyOptions.SchemaSeverity, err = validate.ParseSeverity(schemaSeverity); err != nil {
+		return err
+	}
+	if verifyOptions.StructuralSchemaSeverity, err = validate.ParseSeverity(structuralSchemaSeverity); err != nil {
+		return err
+	}
+
+	if operatorGroupNamespace != "" {
+		verifyOptions.OperatorGroup = &validate.OperatorGroupTarget{
+			OperatorNamespace: operatorGroupNamespace,
+			TargetNamespaces:  targetNamespaces,
+		}
+	}
+	return nil
 }
 
 var verifyCmd = &cobra.Command{
@@ -26,5 +75,10 @@ func verifyFunc(cmd *cobra.Command, args []string) {
 
 	manifestDirectory := args[0]
 
-	_ = validate.ValidateManifest(manifestDirectory)
+	if err := completeVerifyOptions(); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	_ = validate.ValidateManifestWithOptions(manifestDirectory, verifyOptions)
 }
@@ -0,0 +1,94 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution/reference"
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate/validator"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+)
+
+const infrastructureFeaturesAnnotation = "operators.openshift.io/infrastructure-features"
+
+// knownCapabilities lists the operator capability levels accepted in the
+// `capabilities` annotation.
+var knownCapabilities = []string{"Basic Install", "Seamless Upgrades", "Full Lifecycle", "Deep Insights", "Auto Pilot"}
+
+// knownCategories lists the OperatorHub categories accepted in the
+// `categories` annotation.
+var knownCategories = []string{
+	"AI/Machine Learning",
+	"Application Runtime",
+	"Big Data",
+	"Cloud Provider",
+	"Database",
+	"Developer Tools",
+	"Integration & Delivery",
+	"Logging & Tracing",
+	"Monitoring",
+	"Networking",
+	"OpenShift Optional",
+	"Security",
+	"Storage",
+	"Streaming & Messaging",
+}
+
+// createdAtLayouts lists the timestamp formats accepted in the `createdAt`
+// annotation.
+var createdAtLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"}
+
+// validateAnnotations checks the CSV annotations OperatorHub uses to build
+// an operator's listing.
+func validateAnnotations(csv v1alpha1.ClusterServiceVersion, manifestResult validator.ManifestResult) validator.ManifestResult {
+	annotations := csv.GetAnnotations()
+	for _, key := range []string{"capabilities", "categories", "containerImage", "createdAt", "repository", "support", "description"} {
+		if strings.TrimSpace(annotations[key]) == "" {
+			manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidAnnotation(fmt.Sprintf("Warning: `%s` annotation not found for %s csv", key, csv.GetName()), annotationField(key), ""))
+		}
+	}
+
+	if value, ok := annotations["capabilities"]; ok && value != "" && !containsStrict(knownCapabilities, value) {
+		manifestResult.Errors = append(manifestResult.Errors, validator.InvalidAnnotation(fmt.Sprintf("Error: unknown capability level `%s` in %s csv; expected one of %v", value, csv.GetName(), knownCapabilities), annotationField("capabilities"), value))
+	}
+	for _, category := range splitAnnotationList(annotations["categories"]) {
+		if !containsStrict(knownCategories, category) {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidAnnotation(fmt.Sprintf("Error: unknown category `%s` in %s csv; expected one of %v", category, csv.GetName(), knownCategories), annotationField("categories"), category))
+		}
+	}
+	if value, ok := annotations["createdAt"]; ok && value != "" && !isValidTimestamp(value) {
+		manifestResult.Errors = append(manifestResult.Errors, validator.InvalidAnnotation(fmt.Sprintf("Error: `createdAt` annotation `%s` in %s csv is not a valid timestamp; use RFC 3339, e.g. 2019-10-10T12:00:00Z", value, csv.GetName()), annotationField("createdAt"), value))
+	}
+	if value, ok := annotations["containerImage"]; ok && value != "" {
+		if _, err := reference.ParseNormalizedNamed(value); err != nil {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidAnnotation(fmt.Sprintf("Error: `containerImage` annotation `%s` in %s csv is not a valid image reference: %s", value, csv.GetName(), err), annotationField("containerImage"), value))
+		}
+	}
+	if value, ok := annotations["repository"]; ok && value != "" {
+		if err := checkAbsoluteURL(value); err != nil {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidAnnotation(fmt.Sprintf("Error: `repository` annotation `%s` in %s csv is not valid: %s", value, csv.GetName(), err), annotationField("repository"), value))
+		}
+	}
+	if value, ok := annotations[infrastructureFeaturesAnnotation]; ok {
+		var features []string
+		if err := json.Unmarshal([]byte(value), &features); err != nil {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidAnnotation(fmt.Sprintf("Error: `%s` annotation in %s csv must be a JSON list of strings, e.g. [\"disconnected\"]: %s", infrastructureFeaturesAnnotation, csv.GetName(), err), annotationField(infrastructureFeaturesAnnotation), value))
+		}
+	}
+	return manifestResult
+}
+
+func annotationField(key string) string {
+	return fmt.Sprintf("metadata.annotations.%s", key)
+}
+
+func isValidTimestamp(value string) bool {
+	for _, layout := range createdAtLayouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			return true
+		}
+	}
+	return false
+}
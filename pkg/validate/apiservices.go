@@ -0,0 +1,57 @@
+package validate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate/validator"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// validateOwnedAPIServices checks the owned API service definitions of the
+// CSV against its install strategy and owned CRDs. Deployment names are not
+// checked if the install strategy could not be parsed, i.e. strategy is nil.
+func validateOwnedAPIServices(csv v1alpha1.ClusterServiceVersion, strategy *strategyDetailsDeployment, manifestResult validator.ManifestResult) validator.ManifestResult {
+	owned := csv.Spec.APIServiceDefinitions.Owned
+	if len(owned) == 0 {
+		return manifestResult
+	}
+
+	deployments := map[string]struct{}{}
+	if strategy != nil {
+		for _, deployment := range strategy.DeploymentSpecs {
+			deployments[deployment.Name] = struct{}{}
+		}
+	}
+
+	ownedCRDs := map[schema.GroupVersionKind]struct{}{}
+	for _, crd := range csv.Spec.CustomResourceDefinitions.Owned {
+		if parts := strings.SplitN(crd.Name, ".", 2); len(parts) == 2 {
+			ownedCRDs[schema.GroupVersionKind{Group: parts[1], Version: crd.Version, Kind: crd.Kind}] = struct{}{}
+		}
+	}
+
+	for _, api := range owned {
+		gvk := schema.GroupVersionKind{Group: api.Group, Version: api.Version, Kind: api.Kind}
+		if _, ok := deployments[api.DeploymentName]; strategy != nil && !ok {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidCSV(fmt.Sprintf("Error: owned api service `%s` in %s csv references deployment `%s`, which is not defined in the install strategy", api.Name, csv.GetName(), api.DeploymentName)))
+		}
+		if api.ContainerPort == 0 {
+			manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidCSV(fmt.Sprintf("Warning: `containerPort` not set for owned api service `%s` in %s csv; OLM defaults it to 443", api.Name, csv.GetName())))
+		} else if api.ContainerPort < 0 || api.ContainerPort > 65535 {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidCSV(fmt.Sprintf("Error: owned api service `%s` in %s csv has invalid `containerPort` %d; expected a port between 1 and 65535", api.Name, csv.GetName(), api.ContainerPort)))
+		}
+		if errs := validation.IsDNS1123Subdomain(api.Group); len(errs) != 0 {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidCSV(fmt.Sprintf("Error: owned api service `%s` in %s csv has invalid group `%s`: %s", api.Name, csv.GetName(), api.Group, strings.Join(errs, "; "))))
+		}
+		if errs := validation.IsDNS1035Label(api.Version); len(errs) != 0 {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidCSV(fmt.Sprintf("Error: owned api service `%s` in %s csv has invalid version `%s`: %s", api.Name, csv.GetName(), api.Version, strings.Join(errs, "; "))))
+		}
+		if _, ok := ownedCRDs[gvk]; ok {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidCSV(fmt.Sprintf("Error: %v is owned both as a crd and as an api service in %s csv", gvk, csv.GetName())))
+		}
+	}
+	return manifestResult
+}
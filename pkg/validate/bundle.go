@@ -9,11 +9,13 @@ import (
 	"github.com/ghodss/yaml"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type BundleValidator struct {
 	fileName string
+	options  Options
 	Manifest Manifest
 }
 
@@ -21,7 +23,7 @@ var _ validator.Validator = &BundleValidator{}
 
 func (v *BundleValidator) Validate() (results []validator.ManifestResult) {
 
-	result := bundleInspect(v.Manifest)
+	result := bundleInspect(v.Manifest, v.options)
 	if result.Name == "" {
 		result.Name = v.Manifest.Name
 	}
@@ -47,51 +49,117 @@ func (v BundleValidator) Unmarshal(rawYaml []byte) (interface{}, error) {
 	return nil, fmt.Errorf("Error: unsupported operation; unmarshal not defined for bundle validator")
 }
 
-func bundleInspect(manifest Manifest) validator.ManifestResult {
+func bundleInspect(manifest Manifest, options Options) validator.ManifestResult {
 	manifestResult := validator.ManifestResult{}
 	csvReplacesMap := make(map[string]string)
+	csvsByBundle := make(map[string]v1alpha1.ClusterServiceVersion)
+	crdsByBundle := make(map[string]map[string]v1beta1.CustomResourceDefinition)
 	var csvsInBundle []string
-	for _, bundle := range manifest.Bundle {
+	catalog := options.catalog
+	packageName := ""
+	if pkg, err := readAndUnmarshalPackage(manifest.Package); err == (validator.Error{}) {
+		packageName = pkg.PackageName
+	}
+	if catalog == nil && options.CatalogDir != "" {
+		loaded, catalogResult := LoadCatalog(options.CatalogDir)
+		manifestResult.Errors = append(manifestResult.Errors, catalogResult.Errors...)
+		manifestResult.Warnings = append(manifestResult.Warnings, catalogResult.Warnings...)
+		catalog = &loaded
+	}
+	for bundlePath, bundle := range manifest.Bundle {
 		csv, err := readAndUnmarshalCSV(bundle.CSV)
 		if err != (validator.Error{}) {
 			manifestResult.Errors = append(manifestResult.Errors, err)
 			return manifestResult
 		}
+		csvsByBundle[bundlePath] = csv
 		csvsInBundle = append(csvsInBundle, csv.ObjectMeta.Name)
 		csvReplacesMap[bundle.CSV] = csv.Spec.Replaces
 		if csv.ObjectMeta.Name == csv.Spec.Replaces {
 			manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidCSV(fmt.Sprintf("Warning: `spec.replaces` field matches its own `metadata.Name` for %s CSV. It should contain `metadata.Name` of the old CSV to be replaced", bundle.CSV)))
 		}
-		manifestResult = validateOwnedCRDs(bundle, csv, manifestResult)
+
+		// The CRDs, install strategy and extensions of the CSV are parsed once
+		// here and shared by the checks below. Errors in the install strategy
+		// are reported by the CSV validator.
+		crds, crdErr := getBundleCRDs(bundle)
+		if crdErr != (validator.Error{}) {
+			manifestResult.Errors = append(manifestResult.Errors, crdErr)
+		} else {
+			crdsByBundle[bundlePath] = crds
+		}
+		var strategy *strategyDetailsDeployment
+		if parsed, err := getStrategyDetails(csv); err == (validator.Error{}) {
+			strategy = &parsed
+		}
+		extensions, extensionsErr := readCSVExtensions(bundle.CSV)
+		if extensionsErr != (validator.Error{}) {
+			manifestResult.Errors = append(manifestResult.Errors, extensionsErr)
+		}
+
+		if crdErr == (validator.Error{}) {
+			manifestResult = validateOwnedCRDs(bundle, csv, crds, manifestResult)
+			manifestResult = validateDescriptors(csv, crds, manifestResult)
+		}
+		if strategy != nil && extensionsErr == (validator.Error{}) {
+			manifestResult = validateImages(csv, *strategy, extensions, options, manifestResult)
+		}
+		if crdErr == (validator.Error{}) && extensionsErr == (validator.Error{}) {
+			manifestResult = validateWebhooks(bundle, csv, crds, strategy, extensions, manifestResult)
+		}
+		if crdErr == (validator.Error{}) {
+			manifestResult = validateCRDVersions(bundle, csv, crds, manifestResult)
+		}
+		if catalog != nil {
+			manifestResult = resolveRequiredAPIs(csv, *catalog, manifestResult)
+		}
+		manifestResult = validateBundleMetadata(bundle, csv, packageName, catalog, manifestResult)
 	}
 	manifestResult = checkReplacesForCSVs(csvReplacesMap, csvsInBundle, manifestResult)
+	manifestResult = checkStoredVersionsAcrossBundles(manifest, csvsByBundle, crdsByBundle, manifestResult)
+	manifestResult = checkBreakingCRDChanges(csvsByBundle, crdsByBundle, manifestResult)
+	manifestResult = checkShortNameCollisions(crdsByBundle, manifestResult)
+
+	graph, graphResult := BuildUpgradeGraph(manifest)
+	manifestResult.Errors = append(manifestResult.Errors, graphResult.Errors...)
+	manifestResult = checkUpgradeGraph(graph, manifestResult)
+	manifestResult = checkVersionOrdering(graph, manifestResult)
+	manifestResult = checkChannels(graph, manifestResult)
 	manifestResult = checkDefaultChannelInBundle(manifest.Package, csvsInBundle, manifestResult)
 	return manifestResult
 }
 
 func checkDefaultChannelInBundle(pkgName string, csvsInBundle []string, manifestResult validator.ManifestResult) validator.ManifestResult {
+	pkg, err := readAndUnmarshalPackage(pkgName)
+	if err != (validator.Error{}) {
+		manifestResult.Errors = append(manifestResult.Errors, err)
+		return manifestResult
+	}
+	for _, channel := range pkg.Channels {
+		if !isStringPresent(csvsInBundle, channel.CurrentCSVName) {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidBundle(fmt.Sprintf("Error: currentCSV `%s` for channel name `%s` in package `%s` not found in manifest", channel.CurrentCSVName, channel.Name, pkg.PackageName), channel.CurrentCSVName))
+		}
+	}
+	return manifestResult
+}
+
+func readAndUnmarshalPackage(pkgName string) (registry.PackageManifest, validator.Error) {
 	rawYaml, err := ioutil.ReadFile(pkgName)
 	if err != nil {
-		manifestResult.Errors = append(manifestResult.Errors, validator.IOError(fmt.Sprintf("Error in reading %s file:   #%s ", pkgName, err), pkgName))
-		return manifestResult
+		return registry.PackageManifest{}, validator.IOError(fmt.Sprintf("Error in reading %s file:   #%s ", pkgName, err), pkgName)
 	}
 	v := &PackageValidator{}
 	pkg, err := v.Unmarshal(rawYaml)
 	if err != nil {
-		manifestResult.Errors = append(manifestResult.Errors, validator.InvalidParse(fmt.Sprintf("Error unmarshalling YAML to package manifest type for %s file:  #%s ", pkgName, err), pkgName))
-		return manifestResult
+		return registry.PackageManifest{}, validator.InvalidParse(fmt.Sprintf("Error unmarshalling YAML to package manifest type for %s file:  #%s ", pkgName, err), pkgName)
 	}
 	if pkg, ok := pkg.(registry.PackageManifest); ok {
-		for _, channel := range pkg.Channels {
-			if !isStringPresent(csvsInBundle, channel.CurrentCSVName) {
-				manifestResult.Errors = append(manifestResult.Errors, validator.InvalidBundle(fmt.Sprintf("Error: currentCSV `%s` for channel name `%s` in package `%s` not found in manifest", channel.CurrentCSVName, channel.Name, pkg.PackageName), channel.CurrentCSVName))
-			}
-		}
+		return pkg, validator.Error{}
 	}
-	return manifestResult
+	return registry.PackageManifest{}, validator.InvalidParse(fmt.Sprintf("Error unmarshalling YAML to package manifest type for %s file", pkgName), pkgName)
 }
 
-func validateOwnedCRDs(bundle ManifestBundle, csv v1alpha1.ClusterServiceVersion, manifestResult validator.ManifestResult) validator.ManifestResult {
+func validateOwnedCRDs(bundle ManifestBundle, csv v1alpha1.ClusterServiceVersion, crds map[string]v1beta1.CustomResourceDefinition, manifestResult validator.ManifestResult) validator.ManifestResult {
 	ownedCrdNames := getOwnedCustomResourceDefintionNames(csv)
 	bundleCrdNames, err := getBundleCRDNames(bundle)
 	if err != (validator.Error{}) {
@@ -113,9 +181,47 @@ func validateOwnedCRDs(bundle ManifestBundle, csv v1alpha1.ClusterServiceVersion
 			manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidBundle(fmt.Sprintf("Warning: `%s` crd present in bundle `%s` not defined in csv", crd, bundle.Version), crd))
 		}
 	}
+	return validateOwnedCRDDefinitions(bundle, csv, crds, manifestResult)
+}
+
+// validateOwnedCRDDefinitions cross-checks the kind, version and name of each
+// owned CRD entry in the CSV with the matching CRD of crds, the CRDs in the
+// bundle, and warns about cluster-scoped CRDs owned by an operator limited to
+// single namespaces.
+func validateOwnedCRDDefinitions(bundle ManifestBundle, csv v1alpha1.ClusterServiceVersion, crds map[string]v1beta1.CustomResourceDefinition, manifestResult validator.ManifestResult) validator.ManifestResult {
+	namespaced := isNamespacedOperator(csv)
+	for _, owned := range csv.Spec.CustomResourceDefinitions.Owned {
+		crd, ok := crds[owned.Name]
+		if !ok {
+			continue
+		}
+		if owned.Kind != crd.Spec.Names.Kind {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidBundle(fmt.Sprintf("Error: owned crd `%s` in %s csv has kind `%s` but the crd in bundle %s defines kind `%s`", owned.Name, csv.GetName(), owned.Kind, bundle.Version, crd.Spec.Names.Kind), owned.Kind))
+		}
+		if served := getServedVersions(crd); !isStringPresent(served, owned.Version) {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidBundle(fmt.Sprintf("Error: owned crd `%s` in %s csv has version `%s`, which is not a served version of the crd in bundle %s; served versions are %v", owned.Name, csv.GetName(), owned.Version, bundle.Version, served), owned.Version))
+		}
+		if expected := crd.Spec.Names.Plural + "." + crd.Spec.Group; crd.GetName() != expected {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidBundle(fmt.Sprintf("Error: crd `%s` in bundle %s must be named `%s` (`<plural>.<group>`)", crd.GetName(), bundle.Version, expected), crd.GetName()))
+		}
+		if namespaced && crd.Spec.Scope == v1beta1.ClusterScoped {
+			manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidBundle(fmt.Sprintf("Warning: %s csv only supports namespace-scoped install modes but owns cluster-scoped crd `%s`", csv.GetName(), crd.GetName()), crd.GetName()))
+		}
+	}
 	return manifestResult
 }
 
+// isNamespacedOperator returns true if the CSV supports OwnNamespace or
+// SingleNamespace install modes, but neither MultiNamespace nor AllNamespaces.
+func isNamespacedOperator(csv v1alpha1.ClusterServiceVersion) bool {
+	installModeSet := make(v1alpha1.InstallModeSet)
+	for _, installMode := range csv.Spec.InstallModes {
+		installModeSet[installMode.Type] = installMode.Supported
+	}
+	return (installModeSet[v1alpha1.InstallModeTypeOwnNamespace] || installModeSet[v1alpha1.InstallModeTypeSingleNamespace]) &&
+		!installModeSet[v1alpha1.InstallModeTypeMultiNamespace] && !installModeSet[v1alpha1.InstallModeTypeAllNamespaces]
+}
+
 type CRDObjectMeta struct {
 	metav1.ObjectMeta `json:"metadata"`
 }
@@ -165,6 +271,36 @@ func readAndUnmarshalCSV(pathCSV string) (v1alpha1.ClusterServiceVersion, valida
 	return v1alpha1.ClusterServiceVersion{}, validator.InvalidParse(fmt.Sprintf("Error unmarshalling YAML to OLM's csv type for %s file:  #%s ", pathCSV, err), pathCSV)
 }
 
+func readAndUnmarshalCRD(pathCRD string) (v1beta1.CustomResourceDefinition, validator.Error) {
+	rawYaml, err := ioutil.ReadFile(pathCRD)
+	if err != nil {
+		return v1beta1.CustomResourceDefinition{}, validator.IOError(fmt.Sprintf("Error in reading %s file:   #%s ", pathCRD, err), pathCRD)
+	}
+	v := &CRDValidator{}
+	crd, err := v.Unmarshal(rawYaml)
+	if err != nil {
+		return v1beta1.CustomResourceDefinition{}, validator.InvalidParse(fmt.Sprintf("Error unmarshalling YAML to CRD type for %s file:  #%s ", pathCRD, err), pathCRD)
+	}
+	if crd, ok := crd.(v1beta1.CustomResourceDefinition); ok {
+		return crd, validator.Error{}
+	}
+	return v1beta1.CustomResourceDefinition{}, validator.InvalidParse(fmt.Sprintf("Error unmarshalling YAML to CRD type for %s file:  #%s ", pathCRD, err), pathCRD)
+}
+
+// getBundleCRDs reads every CRD file in the bundle and returns them keyed by
+// `metadata.name`.
+func getBundleCRDs(bundle ManifestBundle) (map[string]v1beta1.CustomResourceDefinition, validator.Error) {
+	crds := make(map[string]v1beta1.CustomResourceDefinition)
+	for _, crdFileName := range bundle.CRDs {
+		crd, err := readAndUnmarshalCRD(crdFileName)
+		if err != (validator.Error{}) {
+			return nil, err
+		}
+		crds[crd.GetName()] = crd
+	}
+	return crds, validator.Error{}
+}
+
 // checkReplacesForCSVs generates an error if value of the `replaces` field in the
 // csv does not match the `metadata.Name` field of the old csv to be replaced.
 // It also generates a warning if the `replaces` field of a csv is empty.
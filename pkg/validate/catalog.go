@@ -0,0 +1,103 @@
+package validate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/blang/semver"
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate/validator"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Catalog is a local collection of operator manifests, one per directory
+// under a common root, used to resolve requirements across operators.
+type Catalog struct {
+	// Root is the directory the catalog was loaded from.
+	Root string
+	// Manifests holds the parsed manifest of each operator in the catalog.
+	Manifests []Manifest
+	// ProvidedAPIs maps each API owned by a CSV in the catalog to the names
+	// of the CSVs owning it.
+	ProvidedAPIs map[schema.GroupVersionKind][]string
+	// Packages maps each package name in the catalog to the versions of its
+	// CSVs.
+	Packages map[string][]semver.Version
+}
+
+// LoadCatalog parses every operator manifest directory directly under root.
+// Directories that fail to parse are skipped and reported as warnings.
+func LoadCatalog(root string) (Catalog, validator.ManifestResult) {
+	catalog := newCatalog(root)
+	manifestResult := validator.ManifestResult{Name: root}
+
+	entries, err := ioutil.ReadDir(root)
+	if err != nil {
+		manifestResult.Errors = append(manifestResult.Errors, validator.IOError(fmt.Sprintf("Error in reading catalog directory %s:   #%s ", root, err), root))
+		return catalog, manifestResult
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		manifestDirectory := filepath.Join(root, entry.Name())
+		manifest, parseResult := ParseDir(manifestDirectory)
+		if len(parseResult.Errors) != 0 {
+			manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidManifestStructure(fmt.Sprintf("Warning: skipping catalog entry %s; its manifest structure is not valid", manifestDirectory)))
+			continue
+		}
+		manifestResult = catalog.add(manifest, manifestResult)
+	}
+	return catalog, manifestResult
+}
+
+func newCatalog(root string) Catalog {
+	return Catalog{Root: root, ProvidedAPIs: map[schema.GroupVersionKind][]string{}, Packages: map[string][]semver.Version{}}
+}
+
+// add records manifest and the versions and APIs of its CSVs in the catalog.
+func (c *Catalog) add(manifest Manifest, manifestResult validator.ManifestResult) validator.ManifestResult {
+	c.Manifests = append(c.Manifests, manifest)
+	// Without a package name the versions of the manifest cannot be
+	// recorded, but its provided APIs still can.
+	pkg, pkgErr := readAndUnmarshalPackage(manifest.Package)
+	if pkgErr != (validator.Error{}) {
+		manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidManifestStructure(fmt.Sprintf("Warning: skipping package versions of %s in catalog: %s", manifest.Package, pkgErr)))
+	}
+	for _, bundle := range manifest.Bundle {
+		if bundle.CSV == "" {
+			continue
+		}
+		csv, err := readAndUnmarshalCSV(bundle.CSV)
+		if err != (validator.Error{}) {
+			manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidManifestStructure(fmt.Sprintf("Warning: skipping %s in catalog: %s", bundle.CSV, err)))
+			continue
+		}
+		if pkgErr == (validator.Error{}) {
+			if version, err := semver.Parse(csv.Spec.Version.String()); err == nil {
+				c.Packages[pkg.PackageName] = append(c.Packages[pkg.PackageName], version)
+			}
+		}
+		provided, _ := getProvidedAPIs(csv, validator.ManifestResult{})
+		for gvk := range provided {
+			c.ProvidedAPIs[gvk] = append(c.ProvidedAPIs[gvk], csv.GetName())
+		}
+	}
+	return manifestResult
+}
+
+// ProvidesPackage returns true if the catalog has a CSV of the package named
+// packageName whose version is in versionRange.
+func (c Catalog) ProvidesPackage(packageName string, versionRange semver.Range) bool {
+	for _, version := range c.Packages[packageName] {
+		if versionRange(version) {
+			return true
+		}
+	}
+	return false
+}
+
+// Provides returns true if any CSV in the catalog owns gvk.
+func (c Catalog) Provides(gvk schema.GroupVersionKind) bool {
+	return len(c.ProvidedAPIs[gvk]) != 0
+}
@@ -0,0 +1,158 @@
+package validate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate/validator"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// PackageSummary is the outcome of validating one package of a catalog.
+type PackageSummary struct {
+	// Directory is the manifest directory of the package.
+	Directory string
+	// Package is the name declared by the package yaml, if it could be read.
+	Package        string
+	DefaultChannel string
+	Channels       int
+	Bundles        int
+	// Errors and Warnings count the findings of validating the manifest of
+	// the package as ValidateManifestWithOptions does.
+	Errors   int
+	Warnings int
+}
+
+// ValidateCatalog validates every operator manifest directory directly under
+// root against the catalog they form, without printing a report for each,
+// then checks the packages against each other: package names must be unique, an API may only be owned by one
+// package and every required API must be provided by some package in the
+// catalog. It returns a summary per package and the cross-package findings.
+func ValidateCatalog(root string, options Options) ([]PackageSummary, validator.ManifestResult) {
+	manifestResult := validator.ManifestResult{Name: root}
+	entries, err := ioutil.ReadDir(root)
+	if err != nil {
+		manifestResult.Errors = append(manifestResult.Errors, validator.IOError(fmt.Sprintf("Error in reading catalog directory %s:   #%s ", root, err), root))
+		return nil, manifestResult
+	}
+
+	// Parse every package once, building the catalog from the manifests that
+	// parse, then validate each of them against that catalog.
+	catalog := newCatalog(root)
+	var summaries []PackageSummary
+	var manifests []Manifest
+	var parseResults [][]validator.ManifestResult
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		manifestDirectory := filepath.Join(root, entry.Name())
+		summary := PackageSummary{Directory: manifestDirectory}
+		manifest, parseResult := parseManifestDirectory(manifestDirectory, false)
+		if manifest.Name == "" {
+			for _, result := range parseResult {
+				summary.Errors += len(result.Errors)
+				summary.Warnings += len(result.Warnings)
+			}
+			manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidManifestStructure(fmt.Sprintf("Warning: skipping catalog entry %s; its manifest structure is not valid", manifestDirectory)))
+		} else {
+			summary.Bundles = len(manifest.Bundle)
+			if pkg, err := readAndUnmarshalPackage(manifest.Package); err == (validator.Error{}) {
+				summary.Package = pkg.PackageName
+				summary.DefaultChannel = pkg.DefaultChannelName
+				summary.Channels = len(pkg.Channels)
+			}
+			manifestResult = catalog.add(manifest, manifestResult)
+		}
+		summaries = append(summaries, summary)
+		manifests = append(manifests, manifest)
+		parseResults = append(parseResults, parseResult)
+	}
+
+	options.catalog = &catalog
+	for i, manifest := range manifests {
+		if manifest.Name == "" {
+			continue
+		}
+		for _, result := range validateParsedManifest(manifest, parseResults[i], options, false) {
+			summaries[i].Errors += len(result.Errors)
+			summaries[i].Warnings += len(result.Warnings)
+		}
+	}
+
+	manifestResult = checkDuplicatePackages(summaries, manifestResult)
+	manifestResult = checkAPIOwnership(catalog, manifestResult)
+	for _, manifest := range catalog.Manifests {
+		for _, bundle := range manifest.Bundle {
+			csv, err := readAndUnmarshalCSV(bundle.CSV)
+			if err != (validator.Error{}) {
+				continue
+			}
+			manifestResult = resolveRequiredAPIs(csv, catalog, manifestResult)
+		}
+	}
+	return summaries, manifestResult
+}
+
+// checkDuplicatePackages reports package names declared by more than one
+// manifest directory of the catalog.
+func checkDuplicatePackages(summaries []PackageSummary, manifestResult validator.ManifestResult) validator.ManifestResult {
+	directories := map[string][]string{}
+	var names []string
+	for _, summary := range summaries {
+		if summary.Package == "" {
+			continue
+		}
+		if _, ok := directories[summary.Package]; !ok {
+			names = append(names, summary.Package)
+		}
+		directories[summary.Package] = append(directories[summary.Package], summary.Directory)
+	}
+	for _, name := range names {
+		if len(directories[name]) > 1 {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidManifestStructure(fmt.Sprintf("Error: package `%s` is declared by more than one manifest directory: %s", name, strings.Join(directories[name], ", "))))
+		}
+	}
+	return manifestResult
+}
+
+// checkAPIOwnership reports APIs owned by CSVs of more than one package. OLM
+// refuses to install an operator whose owned APIs are already owned by another
+// operator in the namespace.
+func checkAPIOwnership(catalog Catalog, manifestResult validator.ManifestResult) validator.ManifestResult {
+	owners := map[schema.GroupVersionKind]map[string]struct{}{}
+	for _, manifest := range catalog.Manifests {
+		pkg, err := readAndUnmarshalPackage(manifest.Package)
+		if err != (validator.Error{}) {
+			continue
+		}
+		for _, bundle := range manifest.Bundle {
+			csv, err := readAndUnmarshalCSV(bundle.CSV)
+			if err != (validator.Error{}) {
+				continue
+			}
+			provided, _ := getProvidedAPIs(csv, validator.ManifestResult{})
+			for gvk := range provided {
+				if _, ok := owners[gvk]; !ok {
+					owners[gvk] = map[string]struct{}{}
+				}
+				owners[gvk][pkg.PackageName] = struct{}{}
+			}
+		}
+	}
+
+	var gvks []schema.GroupVersionKind
+	for gvk := range owners {
+		gvks = append(gvks, gvk)
+	}
+	sort.Slice(gvks, func(i, j int) bool { return gvks[i].String() < gvks[j].String() })
+	for _, gvk := range gvks {
+		if len(owners[gvk]) > 1 {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidManifestStructure(fmt.Sprintf("Error: %v is owned by more than one package: %s", gvk, strings.Join(sortedKeys(owners[gvk]), ", "))))
+		}
+	}
+	return manifestResult
+}
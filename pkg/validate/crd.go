@@ -10,12 +10,19 @@ import (
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/conversion"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+const (
+	crdAPIVersionV1beta1 = "apiextensions.k8s.io/v1beta1"
+	crdAPIVersionV1      = "apiextensions.k8s.io/v1"
+)
+
 type CRDValidator struct {
 	fileName string
+	options  Options
 	crds     []v1beta1.CustomResourceDefinition
 }
 
@@ -24,9 +31,13 @@ var _ validator.Validator = &CRDValidator{}
 func (v *CRDValidator) Validate() (results []validator.ManifestResult) {
 	for _, crd := range v.crds {
 		scheme := runtime.NewScheme()
-		result := crdInspect(crd, scheme)
+		result := crdInspect(crd, scheme, v.options)
+		result = validateCRDPresentation(crd, result)
+		if v.fileName != "" {
+			result = rawCRDInspect(v.fileName, v.options, result)
+		}
 		if result.Name == "" {
-			result.Name = crd.GetName()
+			result.Name = fmt.Sprintf("%s (%s)", crd.GetName(), crd.APIVersion)
 		}
 		results = append(results, result)
 	}
@@ -53,6 +64,8 @@ func (v CRDValidator) FileName() string {
 	return v.fileName
 }
 
+// Unmarshal decodes both apiextensions.k8s.io/v1beta1 and v1 CRDs into the
+// v1beta1 type. The `apiVersion` of the file is kept on the returned object.
 func (v CRDValidator) Unmarshal(rawYaml []byte) (interface{}, error) {
 	var crd v1beta1.CustomResourceDefinition
 
@@ -60,13 +73,22 @@ func (v CRDValidator) Unmarshal(rawYaml []byte) (interface{}, error) {
 	if err != nil {
 		return v1beta1.CustomResourceDefinition{}, fmt.Errorf("error parsing raw YAML to Json: %s", err)
 	}
+	typeMeta := metav1.TypeMeta{}
+	if err := json.Unmarshal(rawJson, &typeMeta); err != nil {
+		return v1beta1.CustomResourceDefinition{}, fmt.Errorf("error parsing CRD type meta (JSON) : %s", err)
+	}
+	if typeMeta.APIVersion == crdAPIVersionV1 {
+		if rawJson, err = convertV1CRDJSON(rawJson); err != nil {
+			return v1beta1.CustomResourceDefinition{}, fmt.Errorf("error converting apiextensions.k8s.io/v1 CRD (JSON) : %s", err)
+		}
+	}
 	if err := json.Unmarshal(rawJson, &crd); err != nil {
 		return v1beta1.CustomResourceDefinition{}, fmt.Errorf("error parsing CRD (JSON) : %s", err)
 	}
 	return crd, nil
 }
 
-func crdInspect(crd v1beta1.CustomResourceDefinition, scheme *runtime.Scheme) (manifestResult validator.ManifestResult) {
+func crdInspect(crd v1beta1.CustomResourceDefinition, scheme *runtime.Scheme, options Options) (manifestResult validator.ManifestResult) {
 	err := apiextensions.AddToScheme(scheme)
 	if err != nil {
 		return
@@ -75,14 +97,63 @@ func crdInspect(crd v1beta1.CustomResourceDefinition, scheme *runtime.Scheme) (m
 	if err != nil {
 		return
 	}
+	if crd.APIVersion != crdAPIVersionV1beta1 && crd.APIVersion != crdAPIVersionV1 {
+		manifestResult.Errors = append(manifestResult.Errors, validator.UnsupportedType(fmt.Sprintf("Error: unsupported apiVersion `%s` for crd `%s`; expected `%s` or `%s`", crd.APIVersion, crd.GetName(), crdAPIVersionV1beta1, crdAPIVersionV1)))
+		return
+	}
 	unversionedCRD := apiextensions.CustomResourceDefinition{}
 	scheme.Converter().Convert(&crd, &unversionedCRD, conversion.SourceToDest, nil)
 	errList := validation.ValidateCustomResourceDefinition(&unversionedCRD)
 	for _, err := range errList {
-		if !strings.Contains(err.Field, "openAPIV3Schema") && !strings.Contains(err.Field, "status") {
-			er := validator.Error{Type: validator.ErrorType(err.Type), Field: err.Field, BadValue: err.BadValue, Detail: err.Error()}
-			manifestResult.Errors = append(manifestResult.Errors, er)
+		// `status` is populated by the API server and is not expected to be
+		// part of a manifest.
+		if strings.HasPrefix(err.Field, "status.") {
+			continue
+		}
+		if strings.Contains(err.Field, "openAPIV3Schema") {
+			manifestResult = appendWithSeverity(manifestResult, options.SchemaSeverity, validator.InvalidSchema(err.Error(), err.Field, err.BadValue))
+			continue
 		}
+		er := validator.Error{Type: validator.ErrorType(err.Type), Field: err.Field, BadValue: err.BadValue, Detail: err.Error()}
+		manifestResult.Errors = append(manifestResult.Errors, er)
 	}
 	return
 }
+
+// getCRDSchema returns the openAPIV3Schema of crd for the given version. A
+// per-version schema takes precedence over the top level `spec.validation`.
+// Returns nil if the CRD does not define a schema for the version.
+func getCRDSchema(crd v1beta1.CustomResourceDefinition, version string) *v1beta1.JSONSchemaProps {
+	for _, v := range crd.Spec.Versions {
+		if v.Name == version && v.Schema != nil {
+			return v.Schema.OpenAPIV3Schema
+		}
+	}
+	if crd.Spec.Validation != nil {
+		return crd.Spec.Validation.OpenAPIV3Schema
+	}
+	return nil
+}
+
+// getCRDVersions returns the versions listed by crd. A CRD that only sets the
+// deprecated `spec.version` has that single version, served and stored.
+func getCRDVersions(crd v1beta1.CustomResourceDefinition) []v1beta1.CustomResourceDefinitionVersion {
+	if len(crd.Spec.Versions) != 0 {
+		return crd.Spec.Versions
+	}
+	if crd.Spec.Version == "" {
+		return nil
+	}
+	return []v1beta1.CustomResourceDefinitionVersion{{Name: crd.Spec.Version, Served: true, Storage: true}}
+}
+
+// getServedVersions returns the names of the versions served by crd.
+func getServedVersions(crd v1beta1.CustomResourceDefinition) []string {
+	var served []string
+	for _, version := range getCRDVersions(crd) {
+		if version.Served {
+			served = append(served, version.Name)
+		}
+	}
+	return served
+}
@@ -0,0 +1,130 @@
+package validate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate/validator"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+)
+
+// wellKnownShortNames lists the short names of built-in Kubernetes and
+// OpenShift resources, which CRDs must not shadow.
+var wellKnownShortNames = []string{
+	"cm", "cs", "csr", "ds", "deploy", "ep", "ev", "hpa", "ing", "limits", "netpol", "no", "ns",
+	"pdb", "po", "psp", "pv", "pvc", "pc", "quota", "rc", "rs", "sa", "sc", "sts", "svc",
+	"crd", "crds", "cj", "dc", "bc", "is", "istag", "route",
+}
+
+// validateCRDPresentation checks the printer columns and scale subresource of
+// each served version of crd against the version's schema, and the
+// categories of crd.
+func validateCRDPresentation(crd v1beta1.CustomResourceDefinition, manifestResult validator.ManifestResult) validator.ManifestResult {
+	for _, version := range getServedVersions(crd) {
+		schema := getCRDSchema(crd, version)
+		columns := crd.Spec.AdditionalPrinterColumns
+		subresources := crd.Spec.Subresources
+		for _, v := range crd.Spec.Versions {
+			if v.Name != version {
+				continue
+			}
+			if len(v.AdditionalPrinterColumns) != 0 {
+				columns = v.AdditionalPrinterColumns
+			}
+			if v.Subresources != nil {
+				subresources = v.Subresources
+			}
+		}
+		if schema == nil {
+			continue
+		}
+
+		for _, column := range columns {
+			field := fmt.Sprintf("additionalPrinterColumns[%s].JSONPath", column.Name)
+			if _, found := resolveJSONPath(schema, column.JSONPath); !found {
+				manifestResult.Errors = append(manifestResult.Errors, validator.InvalidSchema(fmt.Sprintf("Error: printer column `%s` of crd `%s` version `%s` uses JSONPath `%s`, which does not resolve to a field in the schema", column.Name, crd.GetName(), version, column.JSONPath), field, column.JSONPath))
+			}
+		}
+
+		if subresources == nil || subresources.Scale == nil {
+			continue
+		}
+		scalePaths := [][2]string{
+			{"specReplicasPath", subresources.Scale.SpecReplicasPath},
+			{"statusReplicasPath", subresources.Scale.StatusReplicasPath},
+		}
+		for _, scalePath := range scalePaths {
+			name, path := scalePath[0], scalePath[1]
+			resolved, found := resolveJSONPath(schema, path)
+			if !found {
+				manifestResult.Errors = append(manifestResult.Errors, validator.InvalidSchema(fmt.Sprintf("Error: scale subresource `%s` `%s` of crd `%s` version `%s` does not resolve to a field in the schema", name, path, crd.GetName(), version), "subresources.scale."+name, path))
+			} else if resolved != nil && resolved.Type != "integer" {
+				manifestResult.Errors = append(manifestResult.Errors, validator.InvalidSchema(fmt.Sprintf("Error: scale subresource `%s` `%s` of crd `%s` version `%s` must point to an integer field; found type `%s`", name, path, crd.GetName(), version, resolved.Type), "subresources.scale."+name, path))
+			}
+		}
+	}
+
+	for _, category := range crd.Spec.Names.Categories {
+		if category == "all" {
+			manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidSchema(fmt.Sprintf("Warning: crd `%s` is in the `all` category; its objects will be listed by `kubectl get all`", crd.GetName()), "spec.names.categories", category))
+		} else if category != strings.ToLower(category) {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidSchema(fmt.Sprintf("Error: category `%s` of crd `%s` must be lowercase", category, crd.GetName()), "spec.names.categories", category))
+		}
+	}
+	return manifestResult
+}
+
+// resolveJSONPath resolves a simple JSONPath such as `.spec.replicas` or
+// `.status.nodes[*].name` through schema. `metadata` fields and filter
+// expressions cannot be verified and are treated as found.
+func resolveJSONPath(schema *v1beta1.JSONSchemaProps, jsonPath string) (*v1beta1.JSONSchemaProps, bool) {
+	path := strings.TrimPrefix(strings.Trim(jsonPath, "{}"), ".")
+	if path == "" {
+		return nil, false
+	}
+	if strings.HasPrefix(path, "metadata.") || strings.Contains(path, "[?") {
+		return nil, true
+	}
+	path = strings.Replace(path, "[*]", "[0]", -1)
+	return resolveSchemaPath(schema, path)
+}
+
+// checkShortNameCollisions reports short names of CRDs in the manifest that
+// shadow built-in resources or are shared by different CRDs. crdsByBundle
+// holds the CRDs of each bundle of the manifest.
+func checkShortNameCollisions(crdsByBundle map[string]map[string]v1beta1.CustomResourceDefinition, manifestResult validator.ManifestResult) validator.ManifestResult {
+	owners := map[string]map[string]struct{}{}
+	var bundlePaths []string
+	for bundlePath := range crdsByBundle {
+		bundlePaths = append(bundlePaths, bundlePath)
+	}
+	sort.Strings(bundlePaths)
+	for _, bundlePath := range bundlePaths {
+		crds := crdsByBundle[bundlePath]
+		for _, name := range sortedCRDNames(crds) {
+			for _, shortName := range crds[name].Spec.Names.ShortNames {
+				if owners[shortName] == nil {
+					owners[shortName] = map[string]struct{}{}
+				}
+				owners[shortName][name] = struct{}{}
+			}
+		}
+	}
+
+	var shortNames []string
+	for shortName := range owners {
+		shortNames = append(shortNames, shortName)
+	}
+	sort.Strings(shortNames)
+	for _, shortName := range shortNames {
+		crdNames := sortedKeys(owners[shortName])
+		if isStringPresent(wellKnownShortNames, shortName) {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidBundle(fmt.Sprintf("Error: short name `%s` of crd %v collides with a built-in Kubernetes resource", shortName, crdNames), shortName))
+		}
+		if len(crdNames) > 1 {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidBundle(fmt.Sprintf("Error: short name `%s` is used by more than one crd: %v", shortName, crdNames), shortName))
+		}
+	}
+	return manifestResult
+}
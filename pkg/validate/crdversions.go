@@ -0,0 +1,135 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate/validator"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+)
+
+// validateCRDVersions checks that every CRD of crds, the CRDs in the bundle,
+// has exactly one storage version and that the versions the CSV refers to in
+// its owned CRDs and examples are served.
+func validateCRDVersions(bundle ManifestBundle, csv v1alpha1.ClusterServiceVersion, crds map[string]v1beta1.CustomResourceDefinition, manifestResult validator.ManifestResult) validator.ManifestResult {
+	crdsByGroupKind := map[string]v1beta1.CustomResourceDefinition{}
+	for name, crd := range crds {
+		if storage := getStorageVersions(crd); len(storage) != 1 {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidBundle(fmt.Sprintf("Error: crd `%s` in bundle %s must have exactly one storage version; found %v", name, bundle.Version, storage), name))
+		}
+		crdsByGroupKind[crd.Spec.Group+"/"+crd.Spec.Names.Kind] = crd
+	}
+
+	var examples []exampleTypeMeta
+	if value := getExamplesAnnotation(csv); value != "" {
+		// Malformed examples are reported by validateExamplesAnnotations.
+		_ = json.Unmarshal([]byte(value), &examples)
+	}
+	for _, example := range examples {
+		parts := strings.SplitN(example.APIVersion, "/", 2)
+		if len(parts) < 2 {
+			continue
+		}
+		crd, ok := crdsByGroupKind[parts[0]+"/"+example.Kind]
+		if !ok {
+			continue
+		}
+		if served := getServedVersions(crd); !isStringPresent(served, parts[1]) {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidBundle(fmt.Sprintf("Error: example `%s` of kind `%s` in %s csv uses version `%s`, which is not served by crd `%s`; served versions are %v", example.APIVersion, example.Kind, csv.GetName(), parts[1], crd.GetName(), served), example.APIVersion))
+		}
+	}
+	return manifestResult
+}
+
+// exampleTypeMeta is the part of an example custom resource needed to find
+// its CRD.
+type exampleTypeMeta struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+}
+
+// getExamplesAnnotation returns the examples annotation of the CSV, preferring
+// `alm-examples` over `olm.examples`.
+func getExamplesAnnotation(csv v1alpha1.ClusterServiceVersion) string {
+	annotations := csv.GetAnnotations()
+	if value, ok := annotations["alm-examples"]; ok {
+		return value
+	}
+	return annotations["olm.examples"]
+}
+
+// getStorageVersions returns the names of the versions marked as storage in
+// crd.
+func getStorageVersions(crd v1beta1.CustomResourceDefinition) []string {
+	var storage []string
+	for _, version := range getCRDVersions(crd) {
+		if version.Storage {
+			storage = append(storage, version.Name)
+		}
+	}
+	return storage
+}
+
+// getDefinedVersions returns the names of every version listed by crd.
+func getDefinedVersions(crd v1beta1.CustomResourceDefinition) []string {
+	var versions []string
+	for _, version := range getCRDVersions(crd) {
+		versions = append(versions, version.Name)
+	}
+	return versions
+}
+
+// checkStoredVersionsAcrossBundles reports CRD versions that an older bundle
+// of the manifest may have stored objects in, but that a newer bundle no
+// longer defines. Bundles are ordered by the `spec.version` of their CSV. The
+// stored versions of an older CRD are taken from `status.storedVersions`, or
+// from its storage version if the status is not set. crdsByBundle holds the
+// CRDs of each bundle; bundles whose CRDs could not be parsed are skipped.
+func checkStoredVersionsAcrossBundles(manifest Manifest, csvsByBundle map[string]v1alpha1.ClusterServiceVersion, crdsByBundle map[string]map[string]v1beta1.CustomResourceDefinition, manifestResult validator.ManifestResult) validator.ManifestResult {
+	var bundlePaths []string
+	for bundlePath := range csvsByBundle {
+		bundlePaths = append(bundlePaths, bundlePath)
+	}
+	sort.Slice(bundlePaths, func(i, j int) bool {
+		return csvsByBundle[bundlePaths[i]].Spec.Version.LessThan(csvsByBundle[bundlePaths[j]].Spec.Version)
+	})
+
+	// storedVersions maps a CRD name to the versions stored by any older
+	// bundle, and to the bundle that last stored each version.
+	storedVersions := map[string]map[string]string{}
+	for _, bundlePath := range bundlePaths {
+		bundle := manifest.Bundle[bundlePath]
+		crds, ok := crdsByBundle[bundlePath]
+		if !ok {
+			continue
+		}
+		for _, name := range sortedCRDNames(crds) {
+			crd := crds[name]
+			defined := getDefinedVersions(crd)
+			previouslyStored := map[string]struct{}{}
+			for version := range storedVersions[name] {
+				previouslyStored[version] = struct{}{}
+			}
+			for _, version := range sortedKeys(previouslyStored) {
+				if olderBundle := storedVersions[name][version]; !isStringPresent(defined, version) {
+					manifestResult.Errors = append(manifestResult.Errors, validator.InvalidBundle(fmt.Sprintf("Error: crd `%s` in bundle %s drops version `%s`, which was stored by the crd in bundle %s. Existing objects stored in that version can no longer be read", name, bundle.Version, version, olderBundle), version))
+				}
+			}
+
+			stored := crd.Status.StoredVersions
+			if len(stored) == 0 {
+				stored = getStorageVersions(crd)
+			}
+			if storedVersions[name] == nil {
+				storedVersions[name] = map[string]string{}
+			}
+			for _, version := range stored {
+				storedVersions[name][version] = bundle.Version
+			}
+		}
+	}
+	return manifestResult
+}
@@ -15,6 +15,7 @@ import (
 
 type CSVValidator struct {
 	fileName string
+	options  Options
 	csvs     []v1alpha1.ClusterServiceVersion
 }
 
@@ -22,7 +23,7 @@ var _ validator.Validator = &CSVValidator{}
 
 func (v *CSVValidator) Validate() (results []validator.ManifestResult) {
 	for _, csv := range v.csvs {
-		result := csvInspect(csv)
+		result := csvInspect(csv, v.options)
 		if result.Name == "" {
 			result.Name = csv.GetName()
 		}
@@ -65,13 +66,37 @@ func (v CSVValidator) Unmarshal(rawYaml []byte) (interface{}, error) {
 }
 
 // Iterates over the given CSV. Returns a ManifestResult type object.
-func csvInspect(csv v1alpha1.ClusterServiceVersion) validator.ManifestResult {
+func csvInspect(csv v1alpha1.ClusterServiceVersion, options Options) validator.ManifestResult {
 
 	// validate example annotations ("alm-examples", "olm.examples").
 	manifestResult := validateExamplesAnnotations(csv)
 
+	// parse the install strategy once for the checks that read it
+	var strategy *strategyDetailsDeployment
+	if parsed, err := getStrategyDetails(csv); err != (validator.Error{}) {
+		manifestResult.Errors = append(manifestResult.Errors, err)
+	} else {
+		strategy = &parsed
+	}
+
 	// validate installModes
 	manifestResult = validateInstallModes(csv, manifestResult)
+	manifestResult = validateInstallModeSemantics(csv, strategy, options, manifestResult)
+
+	// validate required CRDs and API services
+	manifestResult = validateRequiredAPIs(csv, manifestResult)
+
+	// validate owned API services
+	manifestResult = validateOwnedAPIServices(csv, strategy, manifestResult)
+
+	// validate icons
+	manifestResult = validateIcons(csv, options, manifestResult)
+
+	// validate listing metadata
+	manifestResult = validateMetadata(csv, manifestResult)
+
+	// validate OperatorHub annotations
+	manifestResult = validateAnnotations(csv, manifestResult)
 
 	// check missing optional/mandatory fields.
 	fieldValue := reflect.ValueOf(csv)
@@ -0,0 +1,194 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate/validator"
+	"github.com/ghodss/yaml"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Dependency and property types understood by OLM.
+const (
+	typePackage = "olm.package"
+	typeGVK     = "olm.gvk"
+	typeLabel   = "olm.label"
+)
+
+// bundleDependencies is the content of `metadata/dependencies.yaml`.
+type bundleDependencies struct {
+	Dependencies []typedValue `json:"dependencies"`
+}
+
+// bundleProperties is the content of `metadata/properties.yaml`.
+type bundleProperties struct {
+	Properties []typedValue `json:"properties"`
+}
+
+type typedValue struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+type packageValue struct {
+	PackageName string `json:"packageName"`
+	Version     string `json:"version"`
+}
+
+type gvkValue struct {
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+}
+
+type labelValue struct {
+	Label string `json:"label"`
+}
+
+// validateBundleMetadata checks the dependencies and properties files of a
+// bundle. packageName is the name of the package the bundle belongs to; if
+// catalog is set, each dependency must be satisfied by an operator in it.
+func validateBundleMetadata(bundle ManifestBundle, csv v1alpha1.ClusterServiceVersion, packageName string, catalog *Catalog, manifestResult validator.ManifestResult) validator.ManifestResult {
+	if bundle.Dependencies != "" {
+		var dependencies bundleDependencies
+		if err := readStrictYAML(bundle.Dependencies, &dependencies); err != (validator.Error{}) {
+			manifestResult.Errors = append(manifestResult.Errors, err)
+		} else {
+			for i, dependency := range dependencies.Dependencies {
+				manifestResult = validateDependency(bundle.Dependencies, i, dependency, packageName, catalog, manifestResult)
+			}
+		}
+	}
+	if bundle.Properties != "" {
+		var properties bundleProperties
+		if err := readStrictYAML(bundle.Properties, &properties); err != (validator.Error{}) {
+			manifestResult.Errors = append(manifestResult.Errors, err)
+		} else {
+			for i, property := range properties.Properties {
+				manifestResult = validateProperty(bundle.Properties, i, property, packageName, csv, manifestResult)
+			}
+		}
+	}
+	return manifestResult
+}
+
+func validateDependency(fileName string, index int, dependency typedValue, packageName string, catalog *Catalog, manifestResult validator.ManifestResult) validator.ManifestResult {
+	at := fmt.Sprintf("dependencies[%d] in %s", index, fileName)
+	switch dependency.Type {
+	case typePackage:
+		var value packageValue
+		if err := json.Unmarshal(dependency.Value, &value); err != nil {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidDependency(fmt.Sprintf("Error: invalid %s value for %s: %s", typePackage, at, err), dependency.Type))
+			return manifestResult
+		}
+		if value.PackageName == "" {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidDependency(fmt.Sprintf("Error: `packageName` not set for %s", at), dependency.Type))
+		} else if value.PackageName == packageName {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidDependency(fmt.Sprintf("Error: %s depends on its own package `%s`", at, packageName), value.PackageName))
+		}
+		versionRange, err := semver.ParseRange(value.Version)
+		if err != nil {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidDependency(fmt.Sprintf("Error: invalid semver range `%s` for %s: %s", value.Version, at, err), value.Version))
+			return manifestResult
+		}
+		if catalog != nil && value.PackageName != "" && !catalog.ProvidesPackage(value.PackageName, versionRange) {
+			manifestResult.Errors = append(manifestResult.Errors, validator.UnsatisfiedRequirement(fmt.Sprintf("Error: no version of package `%s` in catalog %s satisfies `%s` required by %s", value.PackageName, catalog.Root, value.Version, at), value.PackageName))
+		}
+	case typeGVK:
+		gvk, ok := parseGVKValue(dependency.Value, at, &manifestResult)
+		if ok && catalog != nil && !catalog.Provides(gvk) {
+			manifestResult.Errors = append(manifestResult.Errors, validator.UnsatisfiedRequirement(fmt.Sprintf("Error: %v required by %s is not provided by any operator in catalog %s", gvk, at, catalog.Root), gvk))
+		}
+	case typeLabel:
+		var value labelValue
+		if err := json.Unmarshal(dependency.Value, &value); err != nil || value.Label == "" {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidDependency(fmt.Sprintf("Error: %s value for %s must set a non-empty `label`", typeLabel, at), dependency.Type))
+		}
+	default:
+		manifestResult.Errors = append(manifestResult.Errors, validator.InvalidDependency(fmt.Sprintf("Error: unknown dependency type `%s` for %s; expected one of %s, %s or %s", dependency.Type, at, typePackage, typeGVK, typeLabel), dependency.Type))
+	}
+	return manifestResult
+}
+
+func validateProperty(fileName string, index int, property typedValue, packageName string, csv v1alpha1.ClusterServiceVersion, manifestResult validator.ManifestResult) validator.ManifestResult {
+	at := fmt.Sprintf("properties[%d] in %s", index, fileName)
+	if property.Type == "" {
+		manifestResult.Errors = append(manifestResult.Errors, validator.InvalidDependency(fmt.Sprintf("Error: `type` not set for %s", at), ""))
+		return manifestResult
+	}
+	switch property.Type {
+	case typePackage:
+		var value packageValue
+		if err := json.Unmarshal(property.Value, &value); err != nil {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidDependency(fmt.Sprintf("Error: invalid %s value for %s: %s", typePackage, at, err), property.Type))
+			return manifestResult
+		}
+		if value.PackageName != packageName {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidDependency(fmt.Sprintf("Error: %s declares package `%s` but the bundle belongs to package `%s`", at, value.PackageName, packageName), value.PackageName))
+		}
+		version, err := semver.Parse(value.Version)
+		csvVersion, csvErr := semver.Parse(csv.Spec.Version.String())
+		if err != nil {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidDependency(fmt.Sprintf("Error: invalid semver version `%s` for %s: %s", value.Version, at, err), value.Version))
+		} else if csvErr == nil && !version.EQ(csvVersion) {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidDependency(fmt.Sprintf("Error: %s declares version %s but %s csv has version %s", at, value.Version, csv.GetName(), csv.Spec.Version.String()), value.Version))
+		}
+	case typeGVK:
+		parseGVKValue(property.Value, at, &manifestResult)
+	case typeLabel:
+		var value labelValue
+		if err := json.Unmarshal(property.Value, &value); err != nil || value.Label == "" {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidDependency(fmt.Sprintf("Error: %s value for %s must set a non-empty `label`", typeLabel, at), property.Type))
+		}
+	}
+	return manifestResult
+}
+
+// parseGVKValue decodes and checks an `olm.gvk` value, reporting problems in
+// manifestResult. It returns false if the value is not a valid GVK.
+func parseGVKValue(raw json.RawMessage, at string, manifestResult *validator.ManifestResult) (schema.GroupVersionKind, bool) {
+	var value gvkValue
+	if err := json.Unmarshal(raw, &value); err != nil {
+		manifestResult.Errors = append(manifestResult.Errors, validator.InvalidDependency(fmt.Sprintf("Error: invalid %s value for %s: %s", typeGVK, at, err), typeGVK))
+		return schema.GroupVersionKind{}, false
+	}
+	gvk := schema.GroupVersionKind{Group: value.Group, Version: value.Version, Kind: value.Kind}
+	valid := true
+	if errs := validation.IsDNS1123Subdomain(value.Group); len(errs) != 0 {
+		manifestResult.Errors = append(manifestResult.Errors, validator.InvalidDependency(fmt.Sprintf("Error: invalid group `%s` for %s: %s", value.Group, at, strings.Join(errs, "; ")), value.Group))
+		valid = false
+	}
+	if errs := validation.IsDNS1035Label(value.Version); len(errs) != 0 {
+		manifestResult.Errors = append(manifestResult.Errors, validator.InvalidDependency(fmt.Sprintf("Error: invalid version `%s` for %s: %s", value.Version, at, strings.Join(errs, "; ")), value.Version))
+		valid = false
+	}
+	if value.Kind == "" || strings.ToUpper(value.Kind[:1]) != value.Kind[:1] {
+		manifestResult.Errors = append(manifestResult.Errors, validator.InvalidDependency(fmt.Sprintf("Error: invalid kind `%s` for %s; kinds must be non-empty and start with an upper case letter", value.Kind, at), value.Kind))
+		valid = false
+	}
+	return gvk, valid
+}
+
+// readStrictYAML reads fileName into obj, rejecting unknown fields.
+func readStrictYAML(fileName string, obj interface{}) validator.Error {
+	rawYaml, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return validator.IOError(fmt.Sprintf("Error in reading %s file:   #%s ", fileName, err), fileName)
+	}
+	rawJson, err := yaml.YAMLToJSON(rawYaml)
+	if err != nil {
+		return validator.InvalidParse(fmt.Sprintf("Error in converting to JSON for %s file:   #%s ", fileName, err), fileName)
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(rawJson)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(obj); err != nil {
+		return validator.InvalidParse(fmt.Sprintf("Error parsing %s file:   #%s ", fileName, err), fileName)
+	}
+	return validator.Error{}
+}
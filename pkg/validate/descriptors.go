@@ -0,0 +1,168 @@
+package validate
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate/validator"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+)
+
+const xDescriptorPrefix = "urn:alm:descriptor:"
+
+// knownXDescriptors maps each x-descriptor understood by the OLM console to
+// the openAPIV3Schema types it can be attached to. An empty list means the
+// descriptor is not restricted to a field type.
+var knownXDescriptors = map[string][]string{
+	"urn:alm:descriptor:com.tectonic.ui:podCount":             {"integer"},
+	"urn:alm:descriptor:com.tectonic.ui:podStatuses":          {"object"},
+	"urn:alm:descriptor:com.tectonic.ui:podAffinity":          {"object"},
+	"urn:alm:descriptor:com.tectonic.ui:podAntiAffinity":      {"object"},
+	"urn:alm:descriptor:com.tectonic.ui:nodeAffinity":         {"object"},
+	"urn:alm:descriptor:com.tectonic.ui:resourceRequirements": {"object"},
+	"urn:alm:descriptor:com.tectonic.ui:namespaceSelector":    {"object"},
+	"urn:alm:descriptor:com.tectonic.ui:updateStrategy":       {"object"},
+	"urn:alm:descriptor:com.tectonic.ui:booleanSwitch":        {"boolean"},
+	"urn:alm:descriptor:com.tectonic.ui:checkbox":             {"boolean"},
+	"urn:alm:descriptor:com.tectonic.ui:imagePullPolicy":      {"string"},
+	"urn:alm:descriptor:com.tectonic.ui:text":                 {"string"},
+	"urn:alm:descriptor:com.tectonic.ui:password":             {"string"},
+	"urn:alm:descriptor:com.tectonic.ui:number":               {"integer", "number"},
+	"urn:alm:descriptor:com.tectonic.ui:label":                {"string"},
+	"urn:alm:descriptor:com.tectonic.ui:endpointList":         {"array"},
+	"urn:alm:descriptor:com.tectonic.ui:advanced":             {},
+	"urn:alm:descriptor:com.tectonic.ui:hidden":               {},
+	"urn:alm:descriptor:io.kubernetes.conditions":             {"array"},
+	"urn:alm:descriptor:io.kubernetes.phase":                  {"string"},
+	"urn:alm:descriptor:io.kubernetes.phase:reason":           {"string"},
+	"urn:alm:descriptor:org.w3:link":                          {"string"},
+	"urn:alm:descriptor:prometheusEndpoint":                   {"string"},
+	"urn:alm:descriptor:text":                                 {"string"},
+}
+
+// knownXDescriptorPrefixes lists the parameterized x-descriptors, which must
+// be followed by a non-empty argument, e.g.
+// `urn:alm:descriptor:io.kubernetes:Secret`.
+var knownXDescriptorPrefixes = map[string][]string{
+	"urn:alm:descriptor:io.kubernetes:":                   {"string"},
+	"urn:alm:descriptor:com.tectonic.ui:selector:":        {"object"},
+	"urn:alm:descriptor:com.tectonic.ui:select:":          {"string"},
+	"urn:alm:descriptor:com.tectonic.ui:fieldGroup:":      {},
+	"urn:alm:descriptor:com.tectonic.ui:arrayFieldGroup:": {},
+	"urn:alm:descriptor:com.tectonic.ui:fieldDependency:": {},
+}
+
+// descriptorPathSegment matches a single descriptor path segment, optionally
+// followed by array indices, e.g. `containers[0]`.
+var descriptorPathSegment = regexp.MustCompile(`^([^\[\]]*)((\[[0-9]+\])*)$`)
+
+// validateDescriptors checks the spec and status descriptors of every owned
+// CRD in the CSV against the schema of the matching CRD of crds, the CRDs in
+// the bundle.
+func validateDescriptors(csv v1alpha1.ClusterServiceVersion, crds map[string]v1beta1.CustomResourceDefinition, manifestResult validator.ManifestResult) validator.ManifestResult {
+	for _, owned := range csv.Spec.CustomResourceDefinitions.Owned {
+		var schema *v1beta1.JSONSchemaProps
+		if crd, ok := crds[owned.Name]; ok {
+			schema = getCRDSchema(crd, owned.Version)
+		}
+		for _, descriptor := range owned.SpecDescriptors {
+			manifestResult = validateDescriptor(csv.GetName(), owned.Name, "spec", descriptor.Path, descriptor.XDescriptors, schema, manifestResult)
+		}
+		for _, descriptor := range owned.StatusDescriptors {
+			manifestResult = validateDescriptor(csv.GetName(), owned.Name, "status", descriptor.Path, descriptor.XDescriptors, schema, manifestResult)
+		}
+	}
+	return manifestResult
+}
+
+// validateDescriptor resolves a single descriptor path under the `spec` or
+// `status` (root) field of schema and checks its x-descriptors. Path checks are
+// skipped when the CRD has no schema to resolve against.
+func validateDescriptor(csvName, crdName, root, path string, xDescriptors []string, schema *v1beta1.JSONSchemaProps, manifestResult validator.ManifestResult) validator.ManifestResult {
+	field := fmt.Sprintf("%s.%s", root, path)
+	var fieldSchema *v1beta1.JSONSchemaProps
+	if path == "" {
+		manifestResult.Errors = append(manifestResult.Errors, validator.InvalidDescriptor(fmt.Sprintf("Error: empty `path` in %s descriptor of owned crd `%s` in %s csv", root, crdName, csvName), root, crdName))
+	} else if schema != nil {
+		resolved, found := resolveSchemaPath(schema, field)
+		if !found {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidDescriptor(fmt.Sprintf("Error: %s descriptor path `%s` of owned crd `%s` in %s csv not found in the schema of the crd", root, path, crdName, csvName), field, path))
+		}
+		fieldSchema = resolved
+	}
+
+	for _, xDescriptor := range xDescriptors {
+		allowedTypes, err := lookupXDescriptor(xDescriptor)
+		if err != nil {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidDescriptor(fmt.Sprintf("Error: %s descriptor `%s` of owned crd `%s` in %s csv: %s", root, path, crdName, csvName, err), field, xDescriptor))
+			continue
+		}
+		if fieldSchema == nil || fieldSchema.Type == "" || len(allowedTypes) == 0 {
+			continue
+		}
+		if !containsStrict(allowedTypes, fieldSchema.Type) {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidDescriptor(fmt.Sprintf("Error: x-descriptor `%s` is incompatible with type `%s` of %s descriptor `%s` in owned crd `%s` of %s csv; expected one of %v", xDescriptor, fieldSchema.Type, root, path, crdName, csvName, allowedTypes), field, xDescriptor))
+		}
+	}
+	return manifestResult
+}
+
+// lookupXDescriptor returns the schema types allowed for xDescriptor, or an
+// error if it is malformed or not a known x-descriptor.
+func lookupXDescriptor(xDescriptor string) ([]string, error) {
+	if !strings.HasPrefix(xDescriptor, xDescriptorPrefix) {
+		return nil, fmt.Errorf("malformed x-descriptor `%s`; x-descriptors must start with `%s`", xDescriptor, xDescriptorPrefix)
+	}
+	if allowedTypes, ok := knownXDescriptors[xDescriptor]; ok {
+		return allowedTypes, nil
+	}
+	for prefix, allowedTypes := range knownXDescriptorPrefixes {
+		if strings.HasPrefix(xDescriptor, prefix) {
+			if len(xDescriptor) == len(prefix) {
+				return nil, fmt.Errorf("malformed x-descriptor `%s`; missing argument after `%s`", xDescriptor, prefix)
+			}
+			return allowedTypes, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown x-descriptor `%s`", xDescriptor)
+}
+
+// resolveSchemaPath walks a dot-separated path, such as
+// `spec.containers[0].image`, through schema. It returns the schema of the
+// last path segment and whether the path exists. A nil schema with found set
+// to true means the path enters a free-form object whose fields cannot be
+// verified.
+func resolveSchemaPath(schema *v1beta1.JSONSchemaProps, path string) (*v1beta1.JSONSchemaProps, bool) {
+	current := schema
+	for _, segment := range strings.Split(path, ".") {
+		match := descriptorPathSegment.FindStringSubmatch(segment)
+		if match == nil {
+			return nil, false
+		}
+		if name := match[1]; name != "" {
+			if current.Type != "" && current.Type != "object" {
+				return nil, false
+			}
+			if len(current.Properties) == 0 {
+				return nil, true
+			}
+			next, ok := current.Properties[name]
+			if !ok {
+				return nil, false
+			}
+			current = &next
+		}
+		for i := 0; i < strings.Count(match[2], "["); i++ {
+			if current.Type != "" && current.Type != "array" {
+				return nil, false
+			}
+			if current.Items == nil || current.Items.Schema == nil {
+				return nil, true
+			}
+			current = current.Items.Schema
+		}
+	}
+	return current, true
+}
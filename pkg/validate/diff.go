@@ -0,0 +1,357 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/blang/semver"
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate/validator"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+)
+
+// ManifestDiff summarizes the changes between two versions of an operator
+// manifest.
+type ManifestDiff struct {
+	AddedBundles   []string        `json:"addedBundles,omitempty"`
+	RemovedBundles []string        `json:"removedBundles,omitempty"`
+	DefaultChannel *ValueChange    `json:"defaultChannel,omitempty"`
+	ChannelHeads   []ChannelChange `json:"channelHeads,omitempty"`
+	CSVChanges     []CSVChange     `json:"csvChanges,omitempty"`
+	CRDChanges     []CRDChange     `json:"crdChanges,omitempty"`
+}
+
+// ValueChange is a value that changed from Old to New.
+type ValueChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// ChannelChange is a channel whose head moved. An empty Old or New means the
+// channel was added or removed.
+type ChannelChange struct {
+	Channel string `json:"channel"`
+	Old     string `json:"old"`
+	New     string `json:"new"`
+}
+
+// CSVChange lists the differences between two CSVs, either the same CSV in
+// both manifests, or the old and new head of a channel.
+type CSVChange struct {
+	Old     string   `json:"old"`
+	New     string   `json:"new"`
+	Changes []string `json:"changes"`
+}
+
+// CRDChange describes a CRD added, removed or changed between the latest
+// bundles of both manifests. Changes lists the backward-compatible changes of
+// a changed CRD and Breaking the ones that may reject existing objects.
+type CRDChange struct {
+	Name     string   `json:"name"`
+	Change   string   `json:"change"`
+	Changes  []string `json:"changes,omitempty"`
+	Breaking []string `json:"breaking,omitempty"`
+}
+
+// IsEmpty returns true if the manifests have no differences.
+func (d ManifestDiff) IsEmpty() bool {
+	return len(d.AddedBundles) == 0 && len(d.RemovedBundles) == 0 && d.DefaultChannel == nil &&
+		len(d.ChannelHeads) == 0 && len(d.CSVChanges) == 0 && len(d.CRDChanges) == 0
+}
+
+// DiffManifests compares the bundles, channels, CSVs and CRDs of two parsed
+// versions of an operator manifest.
+func DiffManifests(oldManifest, newManifest Manifest) (ManifestDiff, validator.ManifestResult) {
+	diff := ManifestDiff{}
+	manifestResult := validator.ManifestResult{Name: newManifest.Name}
+
+	oldGraph, oldResult := BuildUpgradeGraph(oldManifest)
+	newGraph, newResult := BuildUpgradeGraph(newManifest)
+	manifestResult.Errors = append(manifestResult.Errors, oldResult.Errors...)
+	manifestResult.Errors = append(manifestResult.Errors, newResult.Errors...)
+
+	for _, node := range newGraph.Nodes {
+		if _, ok := oldGraph.Node(node.Name); !ok {
+			diff.AddedBundles = append(diff.AddedBundles, node.Name)
+		}
+	}
+	for _, node := range oldGraph.Nodes {
+		if _, ok := newGraph.Node(node.Name); !ok {
+			diff.RemovedBundles = append(diff.RemovedBundles, node.Name)
+		}
+	}
+	if oldGraph.DefaultChannel != newGraph.DefaultChannel {
+		diff.DefaultChannel = &ValueChange{Old: oldGraph.DefaultChannel, New: newGraph.DefaultChannel}
+	}
+
+	oldHeads := map[string]string{}
+	for _, channel := range oldGraph.Channels {
+		oldHeads[channel.Name] = channel.Head
+	}
+	newHeads := map[string]string{}
+	for _, channel := range newGraph.Channels {
+		newHeads[channel.Name] = channel.Head
+	}
+	channels := stringSet(nil)
+	for name := range oldHeads {
+		channels[name] = struct{}{}
+	}
+	for name := range newHeads {
+		channels[name] = struct{}{}
+	}
+	// compared holds the old/new CSV pairs to diff field by field.
+	compared := map[[2]string]struct{}{}
+	for _, name := range sortedKeys(channels) {
+		if oldHeads[name] == newHeads[name] {
+			continue
+		}
+		diff.ChannelHeads = append(diff.ChannelHeads, ChannelChange{Channel: name, Old: oldHeads[name], New: newHeads[name]})
+		if oldHeads[name] != "" && newHeads[name] != "" {
+			compared[[2]string{oldHeads[name], newHeads[name]}] = struct{}{}
+		}
+	}
+	for _, node := range newGraph.Nodes {
+		if _, ok := oldGraph.Node(node.Name); ok {
+			compared[[2]string{node.Name, node.Name}] = struct{}{}
+		}
+	}
+
+	var pairs [][2]string
+	for pair := range compared {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i][0]+"\x00"+pairs[i][1] < pairs[j][0]+"\x00"+pairs[j][1]
+	})
+	for _, pair := range pairs {
+		oldNode, _ := oldGraph.Node(pair[0])
+		newNode, _ := newGraph.Node(pair[1])
+		oldCSV, err := readAndUnmarshalCSV(oldManifest.Bundle[oldNode.Bundle].CSV)
+		if err != (validator.Error{}) {
+			manifestResult.Errors = append(manifestResult.Errors, err)
+			continue
+		}
+		newCSV, err := readAndUnmarshalCSV(newManifest.Bundle[newNode.Bundle].CSV)
+		if err != (validator.Error{}) {
+			manifestResult.Errors = append(manifestResult.Errors, err)
+			continue
+		}
+		if changes := diffCSVs(oldCSV, newCSV); len(changes) != 0 {
+			diff.CSVChanges = append(diff.CSVChanges, CSVChange{Old: pair[0], New: pair[1], Changes: changes})
+		}
+	}
+
+	oldCRDs, err := getLatestCRDs(oldManifest, oldGraph)
+	if err != (validator.Error{}) {
+		manifestResult.Errors = append(manifestResult.Errors, err)
+		return diff, manifestResult
+	}
+	newCRDs, err := getLatestCRDs(newManifest, newGraph)
+	if err != (validator.Error{}) {
+		manifestResult.Errors = append(manifestResult.Errors, err)
+		return diff, manifestResult
+	}
+	for _, name := range sortedCRDNames(newCRDs) {
+		oldCRD, ok := oldCRDs[name]
+		if !ok {
+			diff.CRDChanges = append(diff.CRDChanges, CRDChange{Name: name, Change: "added"})
+			continue
+		}
+		newCRD := newCRDs[name]
+		if reflect.DeepEqual(oldCRD.Spec, newCRD.Spec) {
+			continue
+		}
+		change := CRDChange{Name: name, Change: "changed", Changes: diffCRDsCompatible(oldCRD, newCRD), Breaking: diffCRDs(oldCRD, newCRD)}
+		if len(change.Changes) == 0 && len(change.Breaking) == 0 {
+			change.Changes = []string{"spec changed"}
+		}
+		diff.CRDChanges = append(diff.CRDChanges, change)
+	}
+	for _, name := range sortedCRDNames(oldCRDs) {
+		if _, ok := newCRDs[name]; !ok {
+			diff.CRDChanges = append(diff.CRDChanges, CRDChange{Name: name, Change: "removed"})
+		}
+	}
+	return diff, manifestResult
+}
+
+// diffCRDsCompatible describes the backward-compatible changes from oldCRD to
+// newCRD: added served versions, a moved storage version, and schema changes
+// within each version served by both that only accept more objects.
+func diffCRDsCompatible(oldCRD, newCRD v1beta1.CustomResourceDefinition) []string {
+	var changes []string
+	oldServed := getServedVersions(oldCRD)
+	for _, version := range getServedVersions(newCRD) {
+		if !isStringPresent(oldServed, version) {
+			changes = append(changes, fmt.Sprintf("served version `%s` added", version))
+		}
+	}
+	oldStorage, newStorage := getStorageVersions(oldCRD), getStorageVersions(newCRD)
+	if !reflect.DeepEqual(oldStorage, newStorage) {
+		changes = append(changes, fmt.Sprintf("storage version changed from %v to %v", oldStorage, newStorage))
+	}
+	for _, version := range oldServed {
+		oldSchema := getCRDSchema(oldCRD, version)
+		newSchema := getCRDSchema(newCRD, version)
+		if oldSchema == nil || newSchema == nil || !isStringPresent(getServedVersions(newCRD), version) {
+			continue
+		}
+		for _, change := range diffSchemasCompatible(oldSchema, newSchema, "") {
+			changes = append(changes, fmt.Sprintf("version `%s`: %s", version, change))
+		}
+	}
+	return changes
+}
+
+// diffSchemasCompatible recursively compares two schemas and returns the
+// changes that do not reject objects valid under oldSchema.
+func diffSchemasCompatible(oldSchema, newSchema *v1beta1.JSONSchemaProps, path string) []string {
+	var changes []string
+	at := path
+	if at == "" {
+		at = "<root>"
+	}
+
+	if oldSchema.Type != newSchema.Type && isWideningType(oldSchema.Type, newSchema.Type) {
+		changes = append(changes, fmt.Sprintf("`%s` type widened from `%s` to `%s`", at, oldSchema.Type, newSchema.Type))
+	}
+	newRequired := map[string]struct{}{}
+	for _, name := range newSchema.Required {
+		newRequired[name] = struct{}{}
+	}
+	for _, name := range oldSchema.Required {
+		if _, ok := newRequired[name]; !ok {
+			changes = append(changes, fmt.Sprintf("`%s` is no longer required", joinSchemaPath(path, name)))
+		}
+	}
+	if len(oldSchema.Enum) != 0 {
+		oldValues := map[string]struct{}{}
+		for _, value := range oldSchema.Enum {
+			oldValues[string(value.Raw)] = struct{}{}
+		}
+		for _, value := range newSchema.Enum {
+			if _, ok := oldValues[string(value.Raw)]; !ok {
+				changes = append(changes, fmt.Sprintf("`%s` enum value %s added", at, string(value.Raw)))
+			}
+		}
+	}
+	if oldSchema.Description != newSchema.Description {
+		changes = append(changes, fmt.Sprintf("`%s` description changed", at))
+	}
+
+	var names []string
+	for name := range newSchema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		newProperty := newSchema.Properties[name]
+		oldProperty, ok := oldSchema.Properties[name]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("field `%s` added", joinSchemaPath(path, name)))
+			continue
+		}
+		changes = append(changes, diffSchemasCompatible(&oldProperty, &newProperty, joinSchemaPath(path, name))...)
+	}
+	if oldSchema.Items != nil && oldSchema.Items.Schema != nil && newSchema.Items != nil && newSchema.Items.Schema != nil {
+		changes = append(changes, diffSchemasCompatible(oldSchema.Items.Schema, newSchema.Items.Schema, path+"[]")...)
+	}
+	return changes
+}
+
+// diffCSVs describes the changes in permissions, install modes, images and
+// owned APIs from oldCSV to newCSV.
+func diffCSVs(oldCSV, newCSV v1alpha1.ClusterServiceVersion) []string {
+	var changes []string
+
+	oldModes := map[string]bool{}
+	for _, mode := range oldCSV.Spec.InstallModes {
+		oldModes[string(mode.Type)] = mode.Supported
+	}
+	for _, mode := range newCSV.Spec.InstallModes {
+		if supported, ok := oldModes[string(mode.Type)]; !ok || supported != mode.Supported {
+			changes = append(changes, fmt.Sprintf("install mode %s supported: %v", mode.Type, mode.Supported))
+		}
+	}
+
+	oldStrategy, oldErr := getStrategyDetails(oldCSV)
+	newStrategy, newErr := getStrategyDetails(newCSV)
+	if oldErr == (validator.Error{}) && newErr == (validator.Error{}) {
+		changes = append(changes, diffSets("permission", permissionSet(oldStrategy.Permissions), permissionSet(newStrategy.Permissions))...)
+		changes = append(changes, diffSets("cluster permission", permissionSet(oldStrategy.ClusterPermissions), permissionSet(newStrategy.ClusterPermissions))...)
+
+		oldImages := map[string]struct{}{}
+		for _, ref := range collectImages(oldCSV, oldStrategy) {
+			oldImages[ref.Image] = struct{}{}
+		}
+		newImages := map[string]struct{}{}
+		for _, ref := range collectImages(newCSV, newStrategy) {
+			newImages[ref.Image] = struct{}{}
+		}
+		changes = append(changes, diffSets("image", oldImages, newImages)...)
+	}
+
+	oldAPIs, _ := getProvidedAPIs(oldCSV, validator.ManifestResult{})
+	newAPIs, _ := getProvidedAPIs(newCSV, validator.ManifestResult{})
+	oldOwned := map[string]struct{}{}
+	for gvk := range oldAPIs {
+		oldOwned[gvk.String()] = struct{}{}
+	}
+	newOwned := map[string]struct{}{}
+	for gvk := range newAPIs {
+		newOwned[gvk.String()] = struct{}{}
+	}
+	changes = append(changes, diffSets("owned API", oldOwned, newOwned)...)
+	return changes
+}
+
+// permissionSet returns one entry per service account and policy rule.
+func permissionSet(permissions []strategyDeploymentPermissions) map[string]struct{} {
+	set := map[string]struct{}{}
+	for _, permission := range permissions {
+		for _, rule := range permission.Rules {
+			rawRule, _ := json.Marshal(rule)
+			set[fmt.Sprintf("%s: %s", permission.ServiceAccountName, rawRule)] = struct{}{}
+		}
+	}
+	return set
+}
+
+func diffSets(kind string, oldSet, newSet map[string]struct{}) []string {
+	var changes []string
+	for _, item := range sortedKeys(newSet) {
+		if _, ok := oldSet[item]; !ok {
+			changes = append(changes, fmt.Sprintf("%s added: %s", kind, item))
+		}
+	}
+	for _, item := range sortedKeys(oldSet) {
+		if _, ok := newSet[item]; !ok {
+			changes = append(changes, fmt.Sprintf("%s removed: %s", kind, item))
+		}
+	}
+	return changes
+}
+
+// getLatestCRDs returns every CRD of the manifest, taken from the bundle with
+// the highest CSV version that contains it.
+func getLatestCRDs(manifest Manifest, graph UpgradeGraph) (map[string]v1beta1.CustomResourceDefinition, validator.Error) {
+	nodes := append([]GraphNode{}, graph.Nodes...)
+	sort.Slice(nodes, func(i, j int) bool {
+		vi, _ := semver.Parse(nodes[i].Version)
+		vj, _ := semver.Parse(nodes[j].Version)
+		return vi.LT(vj)
+	})
+	latest := map[string]v1beta1.CustomResourceDefinition{}
+	for _, node := range nodes {
+		crds, err := getBundleCRDs(manifest.Bundle[node.Bundle])
+		if err != (validator.Error{}) {
+			return nil, err
+		}
+		for name, crd := range crds {
+			latest[name] = crd
+		}
+	}
+	return latest, validator.Error{}
+}
@@ -0,0 +1,218 @@
+package validate
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/blang/semver"
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate/validator"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry"
+)
+
+const skipRangeAnnotation = "olm.skipRange"
+
+// Edge kinds of the upgrade graph.
+const (
+	EdgeReplaces  = "replaces"
+	EdgeSkips     = "skips"
+	EdgeSkipRange = "skipRange"
+)
+
+// UpgradeGraph is the upgrade graph of an operator manifest, built from the
+// `replaces`, `skips` and `olm.skipRange` of its CSVs and the channels of its
+// package.
+type UpgradeGraph struct {
+	Package        string         `json:"package"`
+	DefaultChannel string         `json:"defaultChannel"`
+	Channels       []GraphChannel `json:"channels"`
+	Nodes          []GraphNode    `json:"nodes"`
+	Edges          []GraphEdge    `json:"edges"`
+	// Orphans lists the CSVs that do not belong to any channel.
+	Orphans []string `json:"orphans"`
+}
+
+// GraphChannel is a channel of the package with the CSVs that belong to it,
+// i.e. that can be reached from its head.
+type GraphChannel struct {
+	Name    string   `json:"name"`
+	Head    string   `json:"head"`
+	Default bool     `json:"default"`
+	Members []string `json:"members"`
+}
+
+// GraphNode is a CSV in the upgrade graph.
+type GraphNode struct {
+	Name      string   `json:"name"`
+	Version   string   `json:"version"`
+	Replaces  string   `json:"replaces,omitempty"`
+	Skips     []string `json:"skips,omitempty"`
+	SkipRange string   `json:"skipRange,omitempty"`
+	Bundle    string   `json:"bundle"`
+}
+
+// GraphEdge is an upgrade from the CSV named From to the CSV named To.
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+}
+
+// BuildUpgradeGraph reads the CSVs and package of manifest and builds its
+// upgrade graph.
+func BuildUpgradeGraph(manifest Manifest) (UpgradeGraph, validator.ManifestResult) {
+	graph := UpgradeGraph{}
+	manifestResult := validator.ManifestResult{Name: manifest.Name}
+
+	pkg, err := readAndUnmarshalPackage(manifest.Package)
+	if err != (validator.Error{}) {
+		manifestResult.Errors = append(manifestResult.Errors, err)
+		return graph, manifestResult
+	}
+	graph.Package = pkg.PackageName
+	graph.DefaultChannel = pkg.DefaultChannelName
+
+	versions := map[string]semver.Version{}
+	for bundlePath, bundle := range manifest.Bundle {
+		if bundle.CSV == "" {
+			continue
+		}
+		csv, err := readAndUnmarshalCSV(bundle.CSV)
+		if err != (validator.Error{}) {
+			manifestResult.Errors = append(manifestResult.Errors, err)
+			continue
+		}
+		extensions, err := readCSVExtensions(bundle.CSV)
+		if err != (validator.Error{}) {
+			manifestResult.Errors = append(manifestResult.Errors, err)
+			continue
+		}
+		version, parseErr := semver.Parse(csv.Spec.Version.String())
+		if parseErr != nil {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidCSV(fmt.Sprintf("Error: invalid `spec.version` `%s` in %s csv: %s", csv.Spec.Version.String(), csv.GetName(), parseErr)))
+			continue
+		}
+		versions[csv.GetName()] = version
+		graph.Nodes = append(graph.Nodes, GraphNode{
+			Name:      csv.GetName(),
+			Version:   csv.Spec.Version.String(),
+			Replaces:  csv.Spec.Replaces,
+			Skips:     extensions.Spec.Skips,
+			SkipRange: csv.GetAnnotations()[skipRangeAnnotation],
+			Bundle:    bundlePath,
+		})
+	}
+	return connectUpgradeGraph(graph, pkg, versions, manifestResult)
+}
+
+// connectUpgradeGraph adds the edges and channels to graph, whose nodes are
+// already set. versions maps the name of each node to its CSV version.
+func connectUpgradeGraph(graph UpgradeGraph, pkg registry.PackageManifest, versions map[string]semver.Version, manifestResult validator.ManifestResult) (UpgradeGraph, validator.ManifestResult) {
+	sort.Slice(graph.Nodes, func(i, j int) bool {
+		return graph.Nodes[i].Name < graph.Nodes[j].Name
+	})
+
+	for _, node := range graph.Nodes {
+		if node.Replaces != "" {
+			graph.Edges = append(graph.Edges, GraphEdge{From: node.Name, To: node.Replaces, Kind: EdgeReplaces})
+		}
+		for _, skipped := range node.Skips {
+			graph.Edges = append(graph.Edges, GraphEdge{From: node.Name, To: skipped, Kind: EdgeSkips})
+		}
+		if node.SkipRange == "" {
+			continue
+		}
+		skipRange, err := semver.ParseRange(node.SkipRange)
+		if err != nil {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidCSV(fmt.Sprintf("Error: invalid `%s` annotation `%s` in %s csv: %s", skipRangeAnnotation, node.SkipRange, node.Name, err)))
+			continue
+		}
+		for _, other := range graph.Nodes {
+			if other.Name != node.Name && skipRange(versions[other.Name]) {
+				graph.Edges = append(graph.Edges, GraphEdge{From: node.Name, To: other.Name, Kind: EdgeSkipRange})
+			}
+		}
+	}
+
+	inChannel := map[string]struct{}{}
+	for _, channel := range pkg.Channels {
+		members := graph.Reachable(channel.CurrentCSVName)
+		for _, member := range members {
+			inChannel[member] = struct{}{}
+		}
+		graph.Channels = append(graph.Channels, GraphChannel{
+			Name:    channel.Name,
+			Head:    channel.CurrentCSVName,
+			Default: channel.Name == pkg.DefaultChannelName,
+			Members: members,
+		})
+	}
+	for _, node := range graph.Nodes {
+		if _, ok := inChannel[node.Name]; !ok {
+			graph.Orphans = append(graph.Orphans, node.Name)
+		}
+	}
+	return graph, manifestResult
+}
+
+// Node returns the node for the CSV named name.
+func (g UpgradeGraph) Node(name string) (GraphNode, bool) {
+	for _, node := range g.Nodes {
+		if node.Name == name {
+			return node, true
+		}
+	}
+	return GraphNode{}, false
+}
+
+// EdgesFrom returns the edges leaving the CSV named name.
+func (g UpgradeGraph) EdgesFrom(name string) []GraphEdge {
+	var edges []GraphEdge
+	for _, edge := range g.Edges {
+		if edge.From == name {
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
+
+// Reachable returns the sorted names of the CSVs in the graph that can be
+// reached from head by following edges, including head itself.
+func (g UpgradeGraph) Reachable(head string) []string {
+	return g.reachableExcept(head, nil)
+}
+
+// reachableExcept is Reachable, but does not enter the CSVs in excluded.
+func (g UpgradeGraph) reachableExcept(head string, excluded map[string]struct{}) []string {
+	if _, ok := g.Node(head); !ok {
+		return nil
+	}
+	visited := map[string]struct{}{head: {}}
+	queue := []string{head}
+	for len(queue) != 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, edge := range g.EdgesFrom(current) {
+			if _, ok := g.Node(edge.To); !ok {
+				continue
+			}
+			if _, ok := excluded[edge.To]; ok {
+				continue
+			}
+			if _, ok := visited[edge.To]; !ok {
+				visited[edge.To] = struct{}{}
+				queue = append(queue, edge.To)
+			}
+		}
+	}
+	return sortedKeys(visited)
+}
+
+// IsHead returns true if the CSV named name is the head of any channel.
+func (g UpgradeGraph) IsHead(name string) bool {
+	for _, channel := range g.Channels {
+		if channel.Head == name {
+			return true
+		}
+	}
+	return false
+}
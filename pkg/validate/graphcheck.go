@@ -0,0 +1,258 @@
+package validate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate/validator"
+)
+
+// checkUpgradeGraph reports replaces cycles, forks within a channel, CSVs
+// that belong to no channel and channels whose head cannot reach the oldest
+// CSV of the channel through `replaces`.
+func checkUpgradeGraph(graph UpgradeGraph, manifestResult validator.ManifestResult) validator.ManifestResult {
+	manifestResult = checkReplacesCycles(graph, manifestResult)
+
+	for _, channel := range graph.Channels {
+		replacedBy := map[string][]string{}
+		for _, member := range channel.Members {
+			if node, ok := graph.Node(member); ok && node.Replaces != "" {
+				replacedBy[node.Replaces] = append(replacedBy[node.Replaces], node.Name)
+			}
+		}
+		var replaced []string
+		for name := range replacedBy {
+			replaced = append(replaced, name)
+		}
+		sort.Strings(replaced)
+		for _, name := range replaced {
+			if len(replacedBy[name]) > 1 {
+				manifestResult.Errors = append(manifestResult.Errors, validator.InvalidBundle(fmt.Sprintf("Error: %v all replace `%s` in channel `%s`; only one CSV may replace a given CSV within a channel", replacedBy[name], name, channel.Name), name))
+			}
+		}
+	}
+
+	for _, orphan := range graph.Orphans {
+		manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidBundle(fmt.Sprintf("Warning: `%s` csv is not reachable from the currentCSV of any channel; it can never be installed or upgraded through", orphan), orphan))
+	}
+
+	for _, channel := range graph.Channels {
+		oldest := oldestNode(graph, channel.Members)
+		root := replacesRoot(graph, channel.Head)
+		if oldest != "" && root != "" && root != oldest {
+			manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidBundle(fmt.Sprintf("Warning: the `replaces` chain from head `%s` of channel `%s` ends at `%s` and cannot reach the oldest csv `%s` of the channel; set `spec.replaces` of `%s` so that users of older versions upgrade through it", channel.Head, channel.Name, root, oldest, root), channel.Name))
+		}
+	}
+	return manifestResult
+}
+
+// replacesRoot follows `replaces` from head and returns the last CSV of the
+// chain that is in graph. It returns an empty string if head is not in graph
+// or the chain forms a cycle.
+func replacesRoot(graph UpgradeGraph, head string) string {
+	node, ok := graph.Node(head)
+	if !ok {
+		return ""
+	}
+	visited := map[string]struct{}{}
+	for {
+		if _, ok := visited[node.Name]; ok {
+			return ""
+		}
+		visited[node.Name] = struct{}{}
+		replaced, ok := graph.Node(node.Replaces)
+		if !ok {
+			return node.Name
+		}
+		node = replaced
+	}
+}
+
+// checkReplacesCycles reports every cycle formed by `replaces` edges.
+func checkReplacesCycles(graph UpgradeGraph, manifestResult validator.ManifestResult) validator.ManifestResult {
+	reported := map[string]struct{}{}
+	for _, start := range graph.Nodes {
+		var path []string
+		onPath := map[string]int{}
+		current := start.Name
+		for current != "" {
+			if index, ok := onPath[current]; ok {
+				cycle := append(append([]string{}, path[index:]...), current)
+				key := sortedKeys(stringSet(cycle))
+				if _, ok := reported[fmt.Sprint(key)]; !ok {
+					reported[fmt.Sprint(key)] = struct{}{}
+					manifestResult.Errors = append(manifestResult.Errors, validator.InvalidBundle(fmt.Sprintf("Error: `spec.replaces` forms a cycle: %v", cycle), cycle))
+				}
+				break
+			}
+			onPath[current] = len(path)
+			path = append(path, current)
+			node, ok := graph.Node(current)
+			if !ok {
+				break
+			}
+			current = node.Replaces
+		}
+	}
+	return manifestResult
+}
+
+// oldestNode returns the name of the CSV with the lowest version among names.
+func oldestNode(graph UpgradeGraph, names []string) string {
+	oldest := ""
+	var oldestVersion semver.Version
+	for _, name := range names {
+		node, ok := graph.Node(name)
+		if !ok {
+			continue
+		}
+		version, err := semver.Parse(node.Version)
+		if err != nil {
+			continue
+		}
+		if oldest == "" || version.LT(oldestVersion) {
+			oldest, oldestVersion = node.Name, version
+		}
+	}
+	return oldest
+}
+
+func stringSet(list []string) map[string]struct{} {
+	set := map[string]struct{}{}
+	for _, item := range list {
+		set[item] = struct{}{}
+	}
+	return set
+}
+
+// checkVersionOrdering reports `replaces` and `skips` edges whose source is
+// not a strictly greater version than their target, and warns about CSVs of
+// the manifest whose version lies between the ends of a `replaces` edge when
+// the replacing CSV covers them with neither `skips` nor `olm.skipRange`.
+func checkVersionOrdering(graph UpgradeGraph, manifestResult validator.ManifestResult) validator.ManifestResult {
+	for _, edge := range graph.Edges {
+		if edge.Kind == EdgeSkipRange {
+			continue
+		}
+		from, fromOK := graph.Node(edge.From)
+		to, toOK := graph.Node(edge.To)
+		if !fromOK || !toOK {
+			continue
+		}
+		fromVersion, err := semver.Parse(from.Version)
+		if err != nil {
+			continue
+		}
+		toVersion, err := semver.Parse(to.Version)
+		if err != nil {
+			continue
+		}
+		if !fromVersion.GT(toVersion) {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidBundle(fmt.Sprintf("Error: `%s` csv (version %s) %s `%s` csv (version %s); the version must increase along every upgrade edge", from.Name, from.Version, edge.Kind, to.Name, to.Version), from.Name))
+			continue
+		}
+		if edge.Kind != EdgeReplaces {
+			continue
+		}
+		if uncovered := uncoveredVersions(graph, from, toVersion, fromVersion); len(uncovered) != 0 {
+			manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidBundle(fmt.Sprintf("Warning: `%s` csv (version %s) replaces `%s` csv (version %s), skipping the intermediate csv(s) %s; add them to `spec.skips` or to the `%s` annotation", from.Name, from.Version, to.Name, to.Version, strings.Join(uncovered, ", "), skipRangeAnnotation), from.Name))
+		}
+	}
+	return manifestResult
+}
+
+// uncoveredVersions returns the sorted names of the CSVs in graph whose
+// version lies strictly between older and newer and that from neither skips
+// nor covers with its skipRange.
+func uncoveredVersions(graph UpgradeGraph, from GraphNode, older, newer semver.Version) []string {
+	skipped := stringSet(from.Skips)
+	var skipRange semver.Range
+	if from.SkipRange != "" {
+		if parsed, err := semver.ParseRange(from.SkipRange); err == nil {
+			skipRange = parsed
+		}
+	}
+	var uncovered []string
+	for _, node := range graph.Nodes {
+		version, err := semver.Parse(node.Version)
+		if err != nil || !version.GT(older) || !version.LT(newer) {
+			continue
+		}
+		if _, ok := skipped[node.Name]; ok {
+			continue
+		}
+		if skipRange != nil && skipRange(version) {
+			continue
+		}
+		uncovered = append(uncovered, fmt.Sprintf("`%s`", node.Name))
+	}
+	sort.Strings(uncovered)
+	return uncovered
+}
+
+// checkChannels verifies that the head of every channel is its highest
+// version, and warns about channels whose only CSV replaces a CSV that is
+// missing from the manifest or that belongs to another channel.
+func checkChannels(graph UpgradeGraph, manifestResult validator.ManifestResult) validator.ManifestResult {
+	for _, channel := range graph.Channels {
+		head, ok := graph.Node(channel.Head)
+		if !ok {
+			continue
+		}
+		headVersion, err := semver.Parse(head.Version)
+		if err != nil {
+			continue
+		}
+		for _, member := range channel.Members {
+			node, _ := graph.Node(member)
+			version, err := semver.Parse(node.Version)
+			if err != nil {
+				continue
+			}
+			if version.GT(headVersion) {
+				manifestResult.Errors = append(manifestResult.Errors, validator.InvalidBundle(fmt.Sprintf("Error: currentCSV `%s` (version %s) of channel `%s` is not the highest version in the channel; `%s` has version %s", head.Name, head.Version, channel.Name, node.Name, node.Version), channel.Name))
+			}
+		}
+		members := ownChannelMembers(graph, channel)
+		if len(members) == 1 && head.Replaces != "" && !isStringPresent(members, head.Replaces) {
+			manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidBundle(fmt.Sprintf("Warning: channel `%s` only contains `%s`, which replaces `%s` from outside the channel", channel.Name, head.Name, head.Replaces), channel.Name))
+		}
+	}
+	return manifestResult
+}
+
+// ownChannelMembers returns the sorted names of the CSVs that can be reached
+// from the head of channel without passing through the head of another
+// channel, i.e. the CSVs that belong to channel rather than to a channel it
+// upgrades from.
+func ownChannelMembers(graph UpgradeGraph, channel GraphChannel) []string {
+	otherHeads := map[string]struct{}{}
+	for _, other := range graph.Channels {
+		if other.Head != channel.Head {
+			otherHeads[other.Head] = struct{}{}
+		}
+	}
+	return graph.reachableExcept(channel.Head, otherHeads)
+}
+
+// ChannelReport describes the CSVs that belong to each channel of graph.
+func ChannelReport(graph UpgradeGraph) string {
+	report := fmt.Sprintf("Channels of package `%s`:\n", graph.Package)
+	for _, channel := range graph.Channels {
+		name := channel.Name
+		if channel.Default {
+			name += " (default)"
+		}
+		report += fmt.Sprintf("  %s: head `%s`, %d csv(s)\n", name, channel.Head, len(channel.Members))
+		for _, member := range channel.Members {
+			node, _ := graph.Node(member)
+			report += fmt.Sprintf("    - %s (%s)\n", node.Name, node.Version)
+		}
+	}
+	if len(graph.Orphans) != 0 {
+		report += fmt.Sprintf("  not in any channel: %v\n", graph.Orphans)
+	}
+	return report
+}
@@ -0,0 +1,133 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Supported upgrade graph output formats.
+const (
+	GraphFormatDOT     = "dot"
+	GraphFormatMermaid = "mermaid"
+	GraphFormatJSON    = "json"
+)
+
+// RenderUpgradeGraph renders graph in the given format.
+func RenderUpgradeGraph(graph UpgradeGraph, format string) (string, error) {
+	switch format {
+	case GraphFormatDOT:
+		return renderDOT(graph), nil
+	case GraphFormatMermaid:
+		return renderMermaid(graph), nil
+	case GraphFormatJSON:
+		out, err := json.MarshalIndent(graph, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(out) + "\n", nil
+	default:
+		return "", fmt.Errorf("unsupported graph format %q; expected one of %s, %s or %s", format, GraphFormatDOT, GraphFormatMermaid, GraphFormatJSON)
+	}
+}
+
+// nodeLabel returns the label of a node: its name, version and the channels
+// it is in, with the default channel marked by `*`.
+func nodeLabel(graph UpgradeGraph, node GraphNode) string {
+	var channels []string
+	for _, channel := range graph.Channels {
+		if isStringPresent(channel.Members, node.Name) {
+			name := channel.Name
+			if channel.Default {
+				name += "*"
+			}
+			channels = append(channels, name)
+		}
+	}
+	label := fmt.Sprintf("%s\\n%s", node.Name, node.Version)
+	if len(channels) != 0 {
+		label += fmt.Sprintf("\\n[%s]", strings.Join(channels, ", "))
+	}
+	return label
+}
+
+func renderDOT(graph UpgradeGraph) string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "digraph %q {\n", graph.Package)
+	b.WriteString("  rankdir=LR;\n  node [shape=box];\n")
+	for _, node := range graph.Nodes {
+		var attrs []string
+		attrs = append(attrs, fmt.Sprintf("label=\"%s\"", nodeLabel(graph, node)))
+		if graph.IsHead(node.Name) {
+			attrs = append(attrs, "style=filled", "fillcolor=lightblue")
+		}
+		for _, channel := range graph.Channels {
+			if channel.Default && channel.Head == node.Name {
+				attrs = append(attrs, "peripheries=2")
+			}
+		}
+		if isStringPresent(graph.Orphans, node.Name) {
+			attrs = append(attrs, "style=dashed", "color=red")
+		}
+		fmt.Fprintf(&b, "  %q [%s];\n", node.Name, strings.Join(attrs, ", "))
+	}
+	for _, edge := range graph.Edges {
+		style := "solid"
+		switch edge.Kind {
+		case EdgeSkips:
+			style = "dashed"
+		case EdgeSkipRange:
+			style = "dotted"
+		}
+		fmt.Fprintf(&b, "  %q -> %q [label=%q, style=%s];\n", edge.From, edge.To, edge.Kind, style)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func renderMermaid(graph UpgradeGraph) string {
+	ids := map[string]string{}
+	id := func(name string) string {
+		if _, ok := ids[name]; !ok {
+			ids[name] = fmt.Sprintf("n%d", len(ids))
+		}
+		return ids[name]
+	}
+
+	var b bytes.Buffer
+	b.WriteString("graph LR\n")
+	for _, node := range graph.Nodes {
+		label := strings.Replace(nodeLabel(graph, node), "\\n", "<br/>", -1)
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", id(node.Name), label)
+	}
+	for _, edge := range graph.Edges {
+		arrow := "-->"
+		if edge.Kind != EdgeReplaces {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "  %s %s|%s| %s\n", id(edge.From), arrow, edge.Kind, id(edge.To))
+	}
+	b.WriteString("  classDef head fill:#add8e6,stroke-width:2px\n")
+	b.WriteString("  classDef defaultHead fill:#add8e6,stroke-width:4px\n")
+	b.WriteString("  classDef orphan stroke:#f00,stroke-dasharray:5 5\n")
+	for _, node := range graph.Nodes {
+		class := ""
+		for _, channel := range graph.Channels {
+			if channel.Head == node.Name {
+				if channel.Default {
+					class = "defaultHead"
+					break
+				}
+				class = "head"
+			}
+		}
+		if isStringPresent(graph.Orphans, node.Name) {
+			class = "orphan"
+		}
+		if class != "" {
+			fmt.Fprintf(&b, "  class %s %s\n", id(node.Name), class)
+		}
+	}
+	return b.String()
+}
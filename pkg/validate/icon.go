@@ -0,0 +1,82 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate/validator"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+)
+
+// iconMagicNumbers maps the supported icon media types to the byte prefixes
+// their decoded data may start with.
+var iconMagicNumbers = map[string][][]byte{
+	"image/png":  {[]byte("\x89PNG\r\n\x1a\n")},
+	"image/jpeg": {[]byte("\xff\xd8\xff")},
+	"image/gif":  {[]byte("GIF87a"), []byte("GIF89a")},
+}
+
+const svgMediaType = "image/svg+xml"
+
+// validateIcons decodes every `spec.icon` entry and checks its media type,
+// contents and size against options.
+func validateIcons(csv v1alpha1.ClusterServiceVersion, options Options, manifestResult validator.ManifestResult) validator.ManifestResult {
+	if len(csv.Spec.Icon) == 0 {
+		manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidIcon(fmt.Sprintf("Warning: no icon found for %s csv. Operators without an icon are displayed with a generic placeholder", csv.GetName()), csv.GetName()))
+		return manifestResult
+	}
+	for i, icon := range csv.Spec.Icon {
+		field := fmt.Sprintf("Spec.Icon[%d]", i)
+		data, err := base64.StdEncoding.DecodeString(icon.Data)
+		if err != nil {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidIcon(fmt.Sprintf("Error: `base64data` of %s in %s csv is not valid base64:  %s", field, csv.GetName(), err), field))
+			continue
+		}
+		if len(data) == 0 {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidIcon(fmt.Sprintf("Error: `base64data` of %s in %s csv is empty", field, csv.GetName()), field))
+			continue
+		}
+		if options.IconMaxBytes > 0 && len(data) > options.IconMaxBytes {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidIcon(fmt.Sprintf("Error: %s in %s csv is %d bytes; icons must not exceed %d bytes", field, csv.GetName(), len(data), options.IconMaxBytes), field))
+		}
+
+		if icon.MediaType == svgMediaType {
+			if !bytes.Contains(data, []byte("<svg")) {
+				manifestResult.Errors = append(manifestResult.Errors, validator.InvalidIcon(fmt.Sprintf("Error: %s in %s csv has mediatype `%s` but its data is not an SVG document", field, csv.GetName(), icon.MediaType), field))
+			}
+			continue
+		}
+		magicNumbers, ok := iconMagicNumbers[icon.MediaType]
+		if !ok {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidIcon(fmt.Sprintf("Error: unsupported mediatype `%s` for %s in %s csv; supported types are image/png, image/jpeg, image/gif and %s", icon.MediaType, field, csv.GetName(), svgMediaType), icon.MediaType))
+			continue
+		}
+		if !hasAnyPrefix(data, magicNumbers) {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidIcon(fmt.Sprintf("Error: %s in %s csv has mediatype `%s` but its data does not match that image format", field, csv.GetName(), icon.MediaType), field))
+			continue
+		}
+		config, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidIcon(fmt.Sprintf("Error: unable to decode %s in %s csv:  %s", field, csv.GetName(), err), field))
+			continue
+		}
+		if (options.IconMaxWidth > 0 && config.Width > options.IconMaxWidth) || (options.IconMaxHeight > 0 && config.Height > options.IconMaxHeight) {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidIcon(fmt.Sprintf("Error: %s in %s csv is %dx%d pixels; icons must not exceed %dx%d pixels", field, csv.GetName(), config.Width, config.Height, options.IconMaxWidth, options.IconMaxHeight), field))
+		}
+	}
+	return manifestResult
+}
+
+func hasAnyPrefix(data []byte, prefixes [][]byte) bool {
+	for _, prefix := range prefixes {
+		if bytes.HasPrefix(data, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package validate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate/validator"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// relatedImageEnvPrefix is the env var prefix operators use by convention to
+// receive the images of their operands.
+const relatedImageEnvPrefix = "RELATED_IMAGE_"
+
+// imageReference is an image found in the CSV along with where it was found.
+type imageReference struct {
+	Image  string
+	Source string
+}
+
+// validateImages checks every image referenced by the CSV and its install
+// strategy, and compares them with `spec.relatedImages`. With
+// options.RequireDigests set, images must be pinned by digest and all of them
+// must be listed in `spec.relatedImages`.
+func validateImages(csv v1alpha1.ClusterServiceVersion, strategy strategyDetailsDeployment, extensions csvExtensions, options Options, manifestResult validator.ManifestResult) validator.ManifestResult {
+
+	referenced := map[string]struct{}{}
+	for _, ref := range collectImages(csv, strategy) {
+		named, err := reference.ParseNormalizedNamed(ref.Image)
+		if err != nil {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidImage(fmt.Sprintf("Error: image `%s` in %s of %s csv is not a valid image reference: %s", ref.Image, ref.Source, csv.GetName(), err), ref.Image))
+			continue
+		}
+		if _, ok := named.(reference.Digested); !ok && options.RequireDigests {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidImage(fmt.Sprintf("Error: image `%s` in %s of %s csv is not pinned by digest", ref.Image, ref.Source, csv.GetName()), ref.Image))
+		}
+		referenced[named.String()] = struct{}{}
+	}
+
+	related := map[string]struct{}{}
+	for _, image := range extensions.Spec.RelatedImages {
+		named, err := reference.ParseNormalizedNamed(image.Image)
+		if err != nil {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidImage(fmt.Sprintf("Error: related image `%s` (%s) in %s csv is not a valid image reference: %s", image.Image, image.Name, csv.GetName(), err), image.Image))
+			continue
+		}
+		if _, ok := named.(reference.Digested); !ok && options.RequireDigests {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidImage(fmt.Sprintf("Error: related image `%s` (%s) in %s csv is not pinned by digest", image.Image, image.Name, csv.GetName()), image.Image))
+		}
+		related[named.String()] = struct{}{}
+	}
+
+	if len(related) == 0 && !options.RequireDigests {
+		if len(referenced) != 0 {
+			manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidImage(fmt.Sprintf("Warning: `spec.relatedImages` not found for %s csv. Listing every image the operator uses is required for disconnected installs", csv.GetName()), csv.GetName()))
+		}
+		return manifestResult
+	}
+	for _, image := range sortedKeys(referenced) {
+		if _, ok := related[image]; !ok {
+			detail := fmt.Sprintf("image `%s` used by %s csv is missing from `spec.relatedImages`", image, csv.GetName())
+			if options.RequireDigests {
+				manifestResult.Errors = append(manifestResult.Errors, validator.InvalidImage("Error: "+detail, image))
+			} else {
+				manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidImage("Warning: "+detail, image))
+			}
+		}
+	}
+	for _, image := range sortedKeys(related) {
+		if _, ok := referenced[image]; !ok {
+			manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidImage(fmt.Sprintf("Warning: related image `%s` in %s csv is not referenced by any deployment or annotation", image, csv.GetName()), image))
+		}
+	}
+	return manifestResult
+}
+
+// collectImages returns the images referenced by the deployment containers,
+// init containers and image env vars of the install strategy, and by the
+// `containerImage` annotation.
+func collectImages(csv v1alpha1.ClusterServiceVersion, strategy strategyDetailsDeployment) []imageReference {
+	var images []imageReference
+	for _, deployment := range strategy.DeploymentSpecs {
+		podSpec := deployment.Spec.Template.Spec
+		for _, container := range podSpec.InitContainers {
+			images = append(images, containerImages(deployment.Name, "init container", container.Name, container.Image, container.Env)...)
+		}
+		for _, container := range podSpec.Containers {
+			images = append(images, containerImages(deployment.Name, "container", container.Name, container.Image, container.Env)...)
+		}
+	}
+	if image := csv.GetAnnotations()["containerImage"]; image != "" {
+		images = append(images, imageReference{Image: image, Source: "`containerImage` annotation"})
+	}
+	return images
+}
+
+func containerImages(deploymentName, kind, containerName, image string, env []corev1.EnvVar) []imageReference {
+	source := fmt.Sprintf("deployment `%s` %s `%s`", deploymentName, kind, containerName)
+	images := []imageReference{{Image: image, Source: source}}
+	for _, envVar := range env {
+		if envVar.Value == "" {
+			continue
+		}
+		if strings.HasPrefix(envVar.Name, relatedImageEnvPrefix) || strings.HasSuffix(envVar.Name, "_IMAGE") {
+			images = append(images, imageReference{Image: envVar.Value, Source: fmt.Sprintf("%s env var `%s`", source, envVar.Name)})
+		}
+	}
+	return images
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
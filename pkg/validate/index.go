@@ -0,0 +1,266 @@
+package validate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate/validator"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry"
+	"github.com/operator-framework/operator-registry/pkg/api"
+	"github.com/operator-framework/operator-registry/pkg/sqlite"
+)
+
+// BuildIndex validates the catalog at catalogRoot with ValidateCatalog and, if
+// it has no errors, loads it into a new operator-registry database at
+// dbFilename.
+func BuildIndex(catalogRoot, dbFilename string, options Options) validator.ManifestResult {
+	manifestResult := validator.ManifestResult{Name: dbFilename}
+	if _, err := os.Stat(dbFilename); err == nil {
+		manifestResult.Errors = append(manifestResult.Errors, validator.InvalidIndex(fmt.Sprintf("Error: %s already exists; remove it or choose another output file", dbFilename), dbFilename))
+		return manifestResult
+	}
+
+	summaries, catalogResult := ValidateCatalog(catalogRoot, options)
+	errorCount := len(catalogResult.Errors)
+	for _, summary := range summaries {
+		errorCount += summary.Errors
+	}
+	if errorCount != 0 {
+		manifestResult.Errors = append(manifestResult.Errors, validator.InvalidIndex(fmt.Sprintf("Error: catalog %s has %d errors; not building index", catalogRoot, errorCount), catalogRoot))
+		return manifestResult
+	}
+
+	loader, err := sqlite.NewSQLLiteLoader(dbFilename)
+	if err != nil {
+		manifestResult.Errors = append(manifestResult.Errors, validator.InvalidIndex(fmt.Sprintf("Error creating index %s:   #%s ", dbFilename, err), dbFilename))
+		os.Remove(dbFilename)
+		return manifestResult
+	}
+	err = sqlite.NewSQLLoaderForDirectory(loader, catalogRoot).Populate()
+	loader.Close()
+	if err != nil {
+		manifestResult.Errors = append(manifestResult.Errors, validator.InvalidIndex(fmt.Sprintf("Error loading catalog %s into index %s:   #%s ", catalogRoot, dbFilename, err), dbFilename))
+		// Do not leave a partially populated index behind.
+		os.Remove(dbFilename)
+	}
+	return manifestResult
+}
+
+// ValidateIndex checks every package of the operator-registry database at
+// dbFilename. The upgrade graph of each package is rebuilt from all of its
+// bundles in the index and checked with the rules applied to manifest
+// directories, so bundles that no channel reaches are reported. It returns
+// one result per package, and one for the index if it has bundles that belong
+// to no package.
+func ValidateIndex(dbFilename string) []validator.ManifestResult {
+	if _, err := os.Stat(dbFilename); err != nil {
+		return []validator.ManifestResult{{Name: dbFilename, Errors: []validator.Error{validator.IOError(fmt.Sprintf("Error in reading %s index:   #%s ", dbFilename, err), dbFilename)}}}
+	}
+	index, err := openIndexDB(dbFilename)
+	if err != nil {
+		return []validator.ManifestResult{{Name: dbFilename, Errors: []validator.Error{validator.InvalidIndex(fmt.Sprintf("Error opening index %s:   #%s ", dbFilename, err), dbFilename)}}}
+	}
+	defer index.Close()
+
+	ctx := context.TODO()
+	packageNames, err := index.listPackages(ctx)
+	if err != nil {
+		return []validator.ManifestResult{{Name: dbFilename, Errors: []validator.Error{validator.InvalidIndex(fmt.Sprintf("Error listing packages of index %s:   #%s ", dbFilename, err), dbFilename)}}}
+	}
+	indexBundles, err := index.listBundles(ctx)
+	if err != nil {
+		return []validator.ManifestResult{{Name: dbFilename, Errors: []validator.Error{validator.InvalidIndex(fmt.Sprintf("Error listing bundles of index %s:   #%s ", dbFilename, err), dbFilename)}}}
+	}
+
+	var results []validator.ManifestResult
+	owned := map[string]struct{}{}
+	for _, packageName := range packageNames {
+		packageBundles := bundlesOfPackage(indexBundles, packageName)
+		for _, bundle := range packageBundles {
+			owned[bundle.name] = struct{}{}
+		}
+		results = append(results, indexPackageInspect(ctx, index, packageName, packageBundles))
+	}
+
+	indexResult := validator.ManifestResult{Name: dbFilename}
+	for _, bundle := range indexBundles {
+		if _, ok := owned[bundle.name]; !ok {
+			indexResult.Warnings = append(indexResult.Warnings, validator.InvalidIndex(fmt.Sprintf("Warning: bundle `%s` in index %s belongs to no package; no channel entry refers to it and its name has no package prefix", bundle.name, dbFilename), bundle.name))
+		}
+	}
+	if len(indexResult.Warnings) != 0 {
+		results = append(results, indexResult)
+	}
+	return results
+}
+
+// bundlesOfPackage returns the bundles that have a channel entry in the
+// package named packageName, and the bundles without any channel entry whose
+// name starts with the package name and a dot, as currentCSV names must.
+func bundlesOfPackage(bundles []indexDBBundle, packageName string) []indexDBBundle {
+	var packageBundles []indexDBBundle
+	for _, bundle := range bundles {
+		if isStringPresent(bundle.packages, packageName) || (len(bundle.packages) == 0 && strings.HasPrefix(bundle.name, packageName+".")) {
+			packageBundles = append(packageBundles, bundle)
+		}
+	}
+	return packageBundles
+}
+
+// indexBundle is a bundle read back from an index.
+type indexBundle struct {
+	csv        v1alpha1.ClusterServiceVersion
+	extensions csvExtensions
+}
+
+func indexPackageInspect(ctx context.Context, index *indexDB, packageName string, packageBundles []indexDBBundle) validator.ManifestResult {
+	manifestResult := validator.ManifestResult{Name: packageName}
+	indexPkg, err := index.getPackage(ctx, packageName)
+	if err != nil {
+		manifestResult.Errors = append(manifestResult.Errors, validator.InvalidIndex(fmt.Sprintf("Error reading package `%s` from index:   #%s ", packageName, err), packageName))
+		return manifestResult
+	}
+	pkg := registry.PackageManifest{PackageName: indexPkg.PackageName, DefaultChannelName: indexPkg.DefaultChannelName}
+	for _, channel := range indexPkg.Channels {
+		pkg.Channels = append(pkg.Channels, registry.PackageChannel{Name: channel.Name, CurrentCSVName: channel.CurrentCSVName})
+	}
+	pkgResult := pkgInspect(pkg)
+	manifestResult.Errors = append(manifestResult.Errors, pkgResult.Errors...)
+	manifestResult.Warnings = append(manifestResult.Warnings, pkgResult.Warnings...)
+
+	// Walk each channel from its head, following `replaces` and `skips`.
+	bundles := map[string]indexBundle{}
+	for _, channel := range pkg.Channels {
+		headBundle, err := index.getBundleForChannel(ctx, packageName, channel.Name)
+		if err != nil {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidIndex(fmt.Sprintf("Error: head `%s` of channel `%s` is not in the index:   #%s ", channel.CurrentCSVName, channel.Name, err), channel.CurrentCSVName))
+			continue
+		}
+		queue := []string{headBundle}
+		for len(queue) != 0 {
+			bundle, err := decodeIndexBundle(queue[0])
+			queue = queue[1:]
+			if err != (validator.Error{}) {
+				manifestResult.Errors = append(manifestResult.Errors, err)
+				continue
+			}
+			name := bundle.csv.GetName()
+			if _, ok := bundles[name]; ok {
+				continue
+			}
+			bundles[name] = bundle
+
+			if replaces := bundle.csv.Spec.Replaces; replaces != "" {
+				if replacedBundle, err := index.getBundle(ctx, packageName, channel.Name, replaces); err != nil {
+					manifestResult.Errors = append(manifestResult.Errors, validator.InvalidIndex(fmt.Sprintf("Error: `%s` csv replaces `%s`, which is not in channel `%s` of the index", name, replaces, channel.Name), replaces))
+				} else {
+					queue = append(queue, replacedBundle)
+				}
+			}
+			for _, skipped := range bundle.extensions.Spec.Skips {
+				if skippedBundle, err := index.getBundle(ctx, packageName, channel.Name, skipped); err == nil {
+					queue = append(queue, skippedBundle)
+				}
+			}
+		}
+	}
+
+	// Add the bundles of the package that no channel head reaches, so that
+	// the graph reports them as orphans.
+	for _, packageBundle := range packageBundles {
+		if _, ok := bundles[packageBundle.name]; ok {
+			continue
+		}
+		bundle, err := decodeIndexBundle(packageBundle.bundle)
+		if err != (validator.Error{}) {
+			manifestResult.Errors = append(manifestResult.Errors, err)
+			continue
+		}
+		bundles[bundle.csv.GetName()] = bundle
+	}
+
+	graph := UpgradeGraph{Package: pkg.PackageName, DefaultChannel: pkg.DefaultChannelName}
+	versions := map[string]semver.Version{}
+	for name, bundle := range bundles {
+		version, err := semver.Parse(bundle.csv.Spec.Version.String())
+		if err != nil {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidCSV(fmt.Sprintf("Error: invalid `spec.version` `%s` in %s csv: %s", bundle.csv.Spec.Version.String(), name, err)))
+			continue
+		}
+		versions[name] = version
+		graph.Nodes = append(graph.Nodes, GraphNode{
+			Name:      name,
+			Version:   bundle.csv.Spec.Version.String(),
+			Replaces:  bundle.csv.Spec.Replaces,
+			Skips:     bundle.extensions.Spec.Skips,
+			SkipRange: bundle.csv.GetAnnotations()[skipRangeAnnotation],
+		})
+	}
+	graph, manifestResult = connectUpgradeGraph(graph, pkg, versions, manifestResult)
+	manifestResult = checkIndexReplacesEdges(ctx, index, graph, manifestResult)
+	manifestResult = checkUpgradeGraph(graph, manifestResult)
+	manifestResult = checkVersionOrdering(graph, manifestResult)
+	manifestResult = checkChannels(graph, manifestResult)
+	return manifestResult
+}
+
+// checkIndexReplacesEdges reports `replaces` fields of CSVs in graph that the
+// index did not record as channel entry edges, i.e. upgrades OLM would not
+// offer when serving the index.
+func checkIndexReplacesEdges(ctx context.Context, index *indexDB, graph UpgradeGraph, manifestResult validator.ManifestResult) validator.ManifestResult {
+	for _, node := range graph.Nodes {
+		if node.Replaces == "" {
+			continue
+		}
+		if _, ok := graph.Node(node.Replaces); !ok {
+			continue
+		}
+		entries, err := index.getChannelEntriesThatReplace(ctx, node.Replaces)
+		if err != nil {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidIndex(fmt.Sprintf("Error reading channel entries that replace `%s` from index:   #%s ", node.Replaces, err), node.Replaces))
+			continue
+		}
+		found := false
+		for _, entry := range entries {
+			if entry.PackageName == graph.Package && entry.BundleName == node.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidIndex(fmt.Sprintf("Error: index has no channel entry for `%s` replacing `%s`", node.Name, node.Replaces), node.Name))
+		}
+	}
+	return manifestResult
+}
+
+// decodeIndexBundle finds the CSV among the objects of a bundle stored in an
+// index.
+func decodeIndexBundle(bundleString string) (indexBundle, validator.Error) {
+	var bundle indexBundle
+	objs, err := api.BundleStringToObjectStrings(bundleString)
+	if err != nil {
+		return bundle, validator.InvalidParse(fmt.Sprintf("Error parsing bundle from index:   #%s ", err), bundleString)
+	}
+	for _, obj := range objs {
+		typeMeta := struct {
+			Kind string `json:"kind"`
+		}{}
+		if err := json.Unmarshal([]byte(obj), &typeMeta); err != nil || typeMeta.Kind != "ClusterServiceVersion" {
+			continue
+		}
+		if err := json.Unmarshal([]byte(obj), &bundle.csv); err != nil {
+			return bundle, validator.InvalidParse(fmt.Sprintf("Error parsing csv from index:   #%s ", err), obj)
+		}
+		if err := json.Unmarshal([]byte(obj), &bundle.extensions); err != nil {
+			return bundle, validator.InvalidParse(fmt.Sprintf("Error parsing csv from index:   #%s ", err), obj)
+		}
+		return bundle, validator.Error{}
+	}
+	return bundle, validator.InvalidIndex("Error: bundle in index has no ClusterServiceVersion", bundleString)
+}
@@ -0,0 +1,160 @@
+package validate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/operator-framework/operator-registry/pkg/registry"
+)
+
+// indexDB reads an operator-registry database with the queries of
+// sqlite.SQLQuerier. Unlike SQLQuerier it can be closed, reads every channel
+// of a package and lists the bundles that no channel reaches. The sqlite3
+// driver is registered by the operator-registry sqlite package.
+type indexDB struct {
+	db *sql.DB
+}
+
+// indexDBBundle is a row of the operatorbundle table.
+type indexDBBundle struct {
+	name   string
+	bundle string
+	// packages lists the packages with a channel entry for the bundle.
+	packages []string
+}
+
+func openIndexDB(dbFilename string) (*indexDB, error) {
+	db, err := sql.Open("sqlite3", "file:"+dbFilename+"?immutable=true")
+	if err != nil {
+		return nil, err
+	}
+	return &indexDB{db: db}, nil
+}
+
+func (i *indexDB) Close() error {
+	return i.db.Close()
+}
+
+func (i *indexDB) listPackages(ctx context.Context) ([]string, error) {
+	rows, err := i.db.QueryContext(ctx, `SELECT DISTINCT name FROM package ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var packages []string
+	for rows.Next() {
+		var name sql.NullString
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		if name.Valid {
+			packages = append(packages, name.String)
+		}
+	}
+	return packages, rows.Err()
+}
+
+func (i *indexDB) getPackage(ctx context.Context, name string) (registry.PackageManifest, error) {
+	pkg := registry.PackageManifest{}
+	rows, err := i.db.QueryContext(ctx, `SELECT DISTINCT package.name, default_channel, channel.name, channel.head_operatorbundle_name
+		FROM package INNER JOIN channel ON channel.package_name=package.name
+		WHERE package.name=? ORDER BY channel.name`, name)
+	if err != nil {
+		return pkg, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pkgName, defaultChannel, channelName, head sql.NullString
+		if err := rows.Scan(&pkgName, &defaultChannel, &channelName, &head); err != nil {
+			return pkg, err
+		}
+		pkg.PackageName = pkgName.String
+		pkg.DefaultChannelName = defaultChannel.String
+		pkg.Channels = append(pkg.Channels, registry.PackageChannel{Name: channelName.String, CurrentCSVName: head.String})
+	}
+	if err := rows.Err(); err != nil {
+		return pkg, err
+	}
+	if pkg.PackageName == "" {
+		return pkg, fmt.Errorf("package %s not found", name)
+	}
+	return pkg, nil
+}
+
+func (i *indexDB) getBundleForChannel(ctx context.Context, pkgName, channelName string) (string, error) {
+	return i.queryBundle(ctx, `SELECT DISTINCT operatorbundle.bundle
+		FROM channel INNER JOIN operatorbundle ON channel.head_operatorbundle_name=operatorbundle.name
+		WHERE channel.package_name=? AND channel.name=? LIMIT 1`, pkgName, channelName)
+}
+
+func (i *indexDB) getBundle(ctx context.Context, pkgName, channelName, csvName string) (string, error) {
+	return i.queryBundle(ctx, `SELECT DISTINCT operatorbundle.bundle
+		FROM operatorbundle INNER JOIN channel_entry ON operatorbundle.name=channel_entry.operatorbundle_name
+		WHERE channel_entry.package_name=? AND channel_entry.channel_name=? AND operatorbundle.name=? LIMIT 1`, pkgName, channelName, csvName)
+}
+
+// queryBundle returns the bundle of the first row of query, or an error if
+// there is none.
+func (i *indexDB) queryBundle(ctx context.Context, query string, args ...interface{}) (string, error) {
+	var bundle sql.NullString
+	err := i.db.QueryRowContext(ctx, query, args...).Scan(&bundle)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("no bundle found for %v", args)
+	}
+	return bundle.String, err
+}
+
+// getChannelEntriesThatReplace returns the channel entries that replace the
+// CSV named name. Unlike SQLQuerier it does not treat finding none as an
+// error.
+func (i *indexDB) getChannelEntriesThatReplace(ctx context.Context, name string) ([]registry.ChannelEntry, error) {
+	rows, err := i.db.QueryContext(ctx, `SELECT DISTINCT channel_entry.package_name, channel_entry.channel_name, channel_entry.operatorbundle_name
+		FROM channel_entry
+		LEFT OUTER JOIN channel_entry replaces ON channel_entry.replaces = replaces.entry_id
+		WHERE replaces.operatorbundle_name = ?`, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []registry.ChannelEntry
+	for rows.Next() {
+		var pkgName, channelName, bundleName sql.NullString
+		if err := rows.Scan(&pkgName, &channelName, &bundleName); err != nil {
+			return nil, err
+		}
+		entries = append(entries, registry.ChannelEntry{PackageName: pkgName.String, ChannelName: channelName.String, BundleName: bundleName.String, Replaces: name})
+	}
+	return entries, rows.Err()
+}
+
+// listBundles returns every row of the operatorbundle table with the
+// packages that have a channel entry for it, sorted by name.
+func (i *indexDB) listBundles(ctx context.Context) ([]indexDBBundle, error) {
+	rows, err := i.db.QueryContext(ctx, `SELECT DISTINCT operatorbundle.name, operatorbundle.bundle, channel_entry.package_name
+		FROM operatorbundle LEFT OUTER JOIN channel_entry ON channel_entry.operatorbundle_name=operatorbundle.name
+		ORDER BY operatorbundle.name, channel_entry.package_name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bundles []indexDBBundle
+	for rows.Next() {
+		var name, bundle, pkgName sql.NullString
+		if err := rows.Scan(&name, &bundle, &pkgName); err != nil {
+			return nil, err
+		}
+		if len(bundles) == 0 || bundles[len(bundles)-1].name != name.String {
+			bundles = append(bundles, indexDBBundle{name: name.String, bundle: bundle.String})
+		}
+		if pkgName.Valid {
+			last := &bundles[len(bundles)-1]
+			last.packages = append(last.packages, pkgName.String)
+		}
+	}
+	return bundles, rows.Err()
+}
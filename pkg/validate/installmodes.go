@@ -0,0 +1,110 @@
+package validate
+
+import (
+	"fmt"
+
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate/validator"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+)
+
+// allInstallModeTypes lists every InstallModeType a CSV is expected to declare.
+var allInstallModeTypes = []v1alpha1.InstallModeType{
+	v1alpha1.InstallModeTypeOwnNamespace,
+	v1alpha1.InstallModeTypeSingleNamespace,
+	v1alpha1.InstallModeTypeMultiNamespace,
+	v1alpha1.InstallModeTypeAllNamespaces,
+}
+
+// OperatorGroupTarget describes an OperatorGroup by the namespace the operator
+// is installed in and the namespaces it targets. No target namespaces selects
+// all namespaces.
+type OperatorGroupTarget struct {
+	OperatorNamespace string
+	TargetNamespaces  []string
+}
+
+// validateInstallModeSemantics checks that the declared install modes form a
+// combination OLM handles sensibly, that they agree with the permissions
+// requested by the install strategy, if it could be parsed, and, if
+// options.OperatorGroup is set, that the CSV can be installed into that
+// OperatorGroup.
+func validateInstallModeSemantics(csv v1alpha1.ClusterServiceVersion, strategy *strategyDetailsDeployment, options Options, manifestResult validator.ManifestResult) validator.ManifestResult {
+	if len(csv.Spec.InstallModes) == 0 {
+		return manifestResult
+	}
+	installModeSet := make(v1alpha1.InstallModeSet)
+	for _, installMode := range csv.Spec.InstallModes {
+		installModeSet[installMode.Type] = installMode.Supported
+	}
+
+	for _, installModeType := range allInstallModeTypes {
+		if _, ok := installModeSet[installModeType]; !ok {
+			manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidCSV(fmt.Sprintf("Warning: install mode `%s` not declared in %s csv. Declare all install modes explicitly, setting `supported` accordingly", installModeType, csv.GetName())))
+		}
+	}
+
+	own := installModeSet[v1alpha1.InstallModeTypeOwnNamespace]
+	single := installModeSet[v1alpha1.InstallModeTypeSingleNamespace]
+	multi := installModeSet[v1alpha1.InstallModeTypeMultiNamespace]
+	all := installModeSet[v1alpha1.InstallModeTypeAllNamespaces]
+	if multi && !own {
+		manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidCSV(fmt.Sprintf("Warning: %s csv supports `MultiNamespace` but not `OwnNamespace`; OperatorGroups targeting the operator's own namespace among others will be rejected", csv.GetName())))
+	}
+	if multi && !single {
+		manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidCSV(fmt.Sprintf("Warning: %s csv supports `MultiNamespace` but not `SingleNamespace`; an OperatorGroup with one target namespace will be rejected", csv.GetName())))
+	}
+
+	if strategy != nil {
+		hasPermissions := len(strategy.Permissions) != 0
+		hasClusterPermissions := len(strategy.ClusterPermissions) != 0
+		if all && !own && !single && !multi && hasPermissions && !hasClusterPermissions {
+			manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidCSV(fmt.Sprintf("Warning: %s csv only supports `AllNamespaces` but requests only namespaced `permissions`; consider whether `clusterPermissions` are needed to watch all namespaces", csv.GetName())))
+		}
+		if !all && !multi && hasClusterPermissions {
+			manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidCSV(fmt.Sprintf("Warning: %s csv only supports single namespace install modes but requests `clusterPermissions`; consider limiting them to namespaced `permissions`", csv.GetName())))
+		}
+	}
+
+	if options.OperatorGroup != nil {
+		if err := checkOperatorGroupSupport(installModeSet, *options.OperatorGroup); err != nil {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidCSV(fmt.Sprintf("Error: %s csv cannot be installed in OperatorGroup (namespace `%s`, target namespaces %v): %s", csv.GetName(), options.OperatorGroup.OperatorNamespace, options.OperatorGroup.TargetNamespaces, err)))
+		}
+	}
+	return manifestResult
+}
+
+// checkOperatorGroupSupport mirrors OLM's admission of a CSV into an
+// OperatorGroup and returns an error if the install modes reject target.
+func checkOperatorGroupSupport(installModeSet v1alpha1.InstallModeSet, target OperatorGroupTarget) error {
+	namespaces := target.TargetNamespaces
+	if len(namespaces) == 1 && namespaces[0] == "" {
+		namespaces = nil
+	}
+	switch {
+	case len(namespaces) == 0:
+		if !installModeSet[v1alpha1.InstallModeTypeAllNamespaces] {
+			return fmt.Errorf("install mode `%s` not supported", v1alpha1.InstallModeTypeAllNamespaces)
+		}
+	case len(namespaces) == 1 && namespaces[0] == target.OperatorNamespace:
+		if !installModeSet[v1alpha1.InstallModeTypeOwnNamespace] {
+			return fmt.Errorf("install mode `%s` not supported", v1alpha1.InstallModeTypeOwnNamespace)
+		}
+	case len(namespaces) == 1:
+		if !installModeSet[v1alpha1.InstallModeTypeSingleNamespace] {
+			return fmt.Errorf("install mode `%s` not supported", v1alpha1.InstallModeTypeSingleNamespace)
+		}
+	default:
+		if !installModeSet[v1alpha1.InstallModeTypeMultiNamespace] {
+			return fmt.Errorf("install mode `%s` not supported", v1alpha1.InstallModeTypeMultiNamespace)
+		}
+		for _, namespace := range namespaces {
+			if namespace == "" {
+				return fmt.Errorf("target namespaces may not mix all namespaces (\"\") with specific namespaces")
+			}
+			if namespace == target.OperatorNamespace && !installModeSet[v1alpha1.InstallModeTypeOwnNamespace] {
+				return fmt.Errorf("target namespaces include the operator namespace but install mode `%s` is not supported", v1alpha1.InstallModeTypeOwnNamespace)
+			}
+		}
+	}
+	return nil
+}
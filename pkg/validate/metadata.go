@@ -0,0 +1,134 @@
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate/validator"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+)
+
+// knownMaturities lists the `spec.maturity` values recognized by OperatorHub.
+var knownMaturities = []string{"planning", "pre-alpha", "alpha", "beta", "stable", "mature", "inactive", "deprecated"}
+
+const (
+	// maxKeywords and maxCategories bound the number of `spec.keywords` and
+	// `categories` annotation entries shown for a listing.
+	maxKeywords   = 10
+	maxCategories = 5
+)
+
+// validateMetadata checks the content of the listing metadata fields shown
+// on OperatorHub: maintainers, links, provider, keywords, categories and
+// maturity.
+func validateMetadata(csv v1alpha1.ClusterServiceVersion, manifestResult validator.ManifestResult) validator.ManifestResult {
+	manifestResult = validateMaintainers(csv, manifestResult)
+	manifestResult = validateLinks(csv, manifestResult)
+	manifestResult = validateProvider(csv, manifestResult)
+	manifestResult = validateKeywords(csv, manifestResult)
+
+	if csv.Spec.Maturity != "" && !containsStrict(knownMaturities, csv.Spec.Maturity) {
+		manifestResult.Errors = append(manifestResult.Errors, validator.InvalidMetadata(fmt.Sprintf("Error: unknown maturity `%s` in %s csv; expected one of %v", csv.Spec.Maturity, csv.GetName(), knownMaturities), "Spec.Maturity", csv.Spec.Maturity))
+	}
+	return manifestResult
+}
+
+func validateMaintainers(csv v1alpha1.ClusterServiceVersion, manifestResult validator.ManifestResult) validator.ManifestResult {
+	for i, maintainer := range csv.Spec.Maintainers {
+		field := fmt.Sprintf("Spec.Maintainers[%d]", i)
+		if strings.TrimSpace(maintainer.Name) == "" {
+			manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidMetadata(fmt.Sprintf("Warning: maintainer `name` is empty for %s in %s csv", field, csv.GetName()), field+".Name", maintainer.Name))
+		}
+		if maintainer.Email == "" {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidMetadata(fmt.Sprintf("Error: maintainer `email` is empty for %s in %s csv", field, csv.GetName()), field+".Email", maintainer.Email))
+			continue
+		}
+		// Reject display-name forms such as `Jane <jane@example.com>`; the
+		// field must hold the bare address.
+		address, err := mail.ParseAddress(maintainer.Email)
+		if err != nil || address.Address != maintainer.Email {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidMetadata(fmt.Sprintf("Error: maintainer email `%s` in %s csv is not a valid RFC 5322 address", maintainer.Email, csv.GetName()), field+".Email", maintainer.Email))
+		}
+	}
+	return manifestResult
+}
+
+func validateLinks(csv v1alpha1.ClusterServiceVersion, manifestResult validator.ManifestResult) validator.ManifestResult {
+	for i, link := range csv.Spec.Links {
+		field := fmt.Sprintf("Spec.Links[%d]", i)
+		if strings.TrimSpace(link.Name) == "" {
+			manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidMetadata(fmt.Sprintf("Warning: link `name` is empty for %s in %s csv", field, csv.GetName()), field+".Name", link.Name))
+		}
+		if err := checkAbsoluteURL(link.URL); err != nil {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidMetadata(fmt.Sprintf("Error: link url `%s` in %s csv is not valid: %s", link.URL, csv.GetName(), err), field+".URL", link.URL))
+		}
+	}
+	return manifestResult
+}
+
+func validateProvider(csv v1alpha1.ClusterServiceVersion, manifestResult validator.ManifestResult) validator.ManifestResult {
+	if strings.TrimSpace(csv.Spec.Provider.Name) == "" {
+		manifestResult.Errors = append(manifestResult.Errors, validator.InvalidMetadata(fmt.Sprintf("Error: provider `name` is empty for %s csv", csv.GetName()), "Spec.Provider.Name", csv.Spec.Provider.Name))
+	}
+	if csv.Spec.Provider.URL != "" {
+		if err := checkAbsoluteURL(csv.Spec.Provider.URL); err != nil {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidMetadata(fmt.Sprintf("Error: provider url `%s` in %s csv is not valid: %s", csv.Spec.Provider.URL, csv.GetName(), err), "Spec.Provider.URL", csv.Spec.Provider.URL))
+		}
+	}
+	return manifestResult
+}
+
+func validateKeywords(csv v1alpha1.ClusterServiceVersion, manifestResult validator.ManifestResult) validator.ManifestResult {
+	seen := map[string]struct{}{}
+	for _, keyword := range csv.Spec.Keywords {
+		if strings.TrimSpace(keyword) == "" {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidMetadata(fmt.Sprintf("Error: empty keyword in %s csv", csv.GetName()), "Spec.Keywords", keyword))
+			continue
+		}
+		if _, ok := seen[keyword]; ok {
+			manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidMetadata(fmt.Sprintf("Warning: duplicate keyword `%s` in %s csv", keyword, csv.GetName()), "Spec.Keywords", keyword))
+		}
+		seen[keyword] = struct{}{}
+	}
+	if len(csv.Spec.Keywords) > maxKeywords {
+		manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidMetadata(fmt.Sprintf("Warning: %s csv has %d keywords; consider using at most %d", csv.GetName(), len(csv.Spec.Keywords), maxKeywords), "Spec.Keywords", len(csv.Spec.Keywords)))
+	}
+
+	if categories := splitAnnotationList(csv.GetAnnotations()["categories"]); len(categories) > maxCategories {
+		manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidMetadata(fmt.Sprintf("Warning: %s csv has %d categories; consider using at most %d", csv.GetName(), len(categories), maxCategories), "metadata.annotations.categories", len(categories)))
+	}
+	return manifestResult
+}
+
+// checkAbsoluteURL returns an error unless rawURL is an absolute http(s) URL
+// with a host.
+func checkAbsoluteURL(rawURL string) error {
+	if rawURL == "" {
+		return fmt.Errorf("url is empty")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("url must be absolute with an http or https scheme")
+	}
+	if u.Host == "" {
+		return fmt.Errorf("url has no host")
+	}
+	return nil
+}
+
+// splitAnnotationList splits a comma separated annotation value, dropping
+// surrounding whitespace and empty entries.
+func splitAnnotationList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
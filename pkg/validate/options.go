@@ -0,0 +1,82 @@
+package validate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate/validator"
+)
+
+// Severity controls how a class of findings is reported.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityIgnore  Severity = "ignore"
+)
+
+// ParseSeverity converts s into a Severity.
+func ParseSeverity(s string) (Severity, error) {
+	switch severity := Severity(strings.ToLower(s)); severity {
+	case SeverityError, SeverityWarning, SeverityIgnore:
+		return severity, nil
+	default:
+		return "", fmt.Errorf("unknown severity %q; expected one of %s, %s or %s", s, SeverityError, SeverityWarning, SeverityIgnore)
+	}
+}
+
+// appendWithSeverity adds err to manifestResult as an error or a warning, or
+// drops it, according to severity. The detail of err is prefixed to match.
+func appendWithSeverity(manifestResult validator.ManifestResult, severity Severity, err validator.Error) validator.ManifestResult {
+	switch severity {
+	case SeverityIgnore:
+	case SeverityWarning:
+		err.Detail = "Warning: " + err.Detail
+		manifestResult.Warnings = append(manifestResult.Warnings, err)
+	default:
+		err.Detail = "Error: " + err.Detail
+		manifestResult.Errors = append(manifestResult.Errors, err)
+	}
+	return manifestResult
+}
+
+// Options holds the configurable limits and policies applied while validating
+// an operator manifest. A zero value for a limit disables that check.
+type Options struct {
+	// IconMaxBytes is the maximum size of a decoded `spec.icon` image.
+	IconMaxBytes int
+	// IconMaxWidth is the maximum width of a `spec.icon` image in pixels.
+	IconMaxWidth int
+	// IconMaxHeight is the maximum height of a `spec.icon` image in pixels.
+	IconMaxHeight int
+	// RequireDigests requires every image referenced by a CSV to be pinned by
+	// digest and listed in `spec.relatedImages`.
+	RequireDigests bool
+	// OperatorGroup, if set, is checked against each CSV's install modes to
+	// simulate whether OLM would accept the CSV in that OperatorGroup.
+	OperatorGroup *OperatorGroupTarget
+	// CatalogDir, if set, is a directory of other operators' manifests used
+	// to check that required APIs are provided by some operator.
+	CatalogDir string
+	// catalog, if set, is used instead of loading CatalogDir, so that a
+	// catalog is loaded once when validating many manifests against it.
+	catalog *Catalog
+	// SchemaSeverity is the severity of openAPIV3Schema validation findings.
+	SchemaSeverity Severity
+	// StructuralSchemaSeverity is the severity of findings that make a CRD
+	// schema non-structural, as rejected by apiextensions.k8s.io/v1.
+	StructuralSchemaSeverity Severity
+}
+
+// DefaultOptions returns the Options used by ValidateManifest.
+func DefaultOptions() Options {
+	return Options{
+		IconMaxBytes:  100 * 1024,
+		IconMaxWidth:  256,
+		IconMaxHeight: 256,
+
+		SchemaSeverity:           SeverityError,
+		StructuralSchemaSeverity: SeverityWarning,
+	}
+}
@@ -3,10 +3,12 @@ package validate
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/dweepgogia/new-manifest-verification/pkg/validate/validator"
 	"github.com/ghodss/yaml"
 	"github.com/operator-framework/operator-lifecycle-manager/pkg/controller/registry"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 type PackageValidator struct {
@@ -70,6 +72,18 @@ func (v PackageValidator) Unmarshal(rawYaml []byte) (interface{}, error) {
 
 func pkgInspect(pkg registry.PackageManifest) (manifestResult validator.ManifestResult) {
 	manifestResult = validator.ManifestResult{}
+	if pkg.PackageName == "" {
+		manifestResult.Errors = append(manifestResult.Errors, validator.MandatoryFieldMissing("Error: `packageName` not found in package manifest", "packageName", ""))
+	} else if errs := validation.IsDNS1123Subdomain(pkg.PackageName); len(errs) != 0 {
+		manifestResult.Errors = append(manifestResult.Errors, validator.InvalidBundle(fmt.Sprintf("Error: invalid packageName `%s`: %s", pkg.PackageName, strings.Join(errs, "; ")), pkg.PackageName))
+	}
+
+	if len(pkg.Channels) == 0 {
+		manifestResult.Errors = append(manifestResult.Errors, validator.MandatoryFieldMissing(fmt.Sprintf("Error: no channels declared in %s package manifest", pkg.PackageName), "channels", pkg.PackageName))
+		return
+	}
+	manifestResult = validateChannels(pkg, manifestResult)
+
 	present, manifestResult := isDefaultPresent(pkg, manifestResult)
 	if !present {
 		manifestResult.Errors = append(manifestResult.Errors, validator.InvalidDefaultChannel(fmt.Sprintf("Error: default channel %s not found in the list of declared channels", pkg.DefaultChannelName), pkg.DefaultChannelName))
@@ -77,15 +91,43 @@ func pkgInspect(pkg registry.PackageManifest) (manifestResult validator.Manifest
 	return
 }
 
-func isDefaultPresent(pkg registry.PackageManifest, manifestResult validator.ManifestResult) (bool, validator.ManifestResult) {
-	present := false
+// validateChannels checks each channel of the package for a name, a unique
+// name and a currentCSV prefixed by the package name and a `.`.
+func validateChannels(pkg registry.PackageManifest, manifestResult validator.ManifestResult) validator.ManifestResult {
+	seen := map[string]struct{}{}
 	for _, channel := range pkg.Channels {
-		if pkg.DefaultChannelName == "" {
+		if channel.Name == "" {
+			manifestResult.Errors = append(manifestResult.Errors, validator.MandatoryFieldMissing(fmt.Sprintf("Error: channel with currentCSV `%s` has no name in %s package manifest", channel.CurrentCSVName, pkg.PackageName), "channels.name", channel.CurrentCSVName))
+		} else if _, ok := seen[channel.Name]; ok {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidBundle(fmt.Sprintf("Error: duplicate channel `%s` in %s package manifest", channel.Name, pkg.PackageName), channel.Name))
+		}
+		seen[channel.Name] = struct{}{}
+
+		if channel.CurrentCSVName == "" {
+			manifestResult.Errors = append(manifestResult.Errors, validator.MandatoryFieldMissing(fmt.Sprintf("Error: `currentCSV` not found for channel `%s` in %s package manifest", channel.Name, pkg.PackageName), "channels.currentCSV", channel.Name))
+		} else if pkg.PackageName != "" && !strings.HasPrefix(channel.CurrentCSVName, pkg.PackageName+".") {
+			manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidBundle(fmt.Sprintf("Warning: currentCSV `%s` of channel `%s` does not start with the package name `%s` followed by `.`", channel.CurrentCSVName, channel.Name, pkg.PackageName), channel.CurrentCSVName))
+		}
+	}
+	return manifestResult
+}
+
+// isDefaultPresent returns true if the default channel of the package is one
+// of its declared channels. A package without a default channel is accepted
+// with a warning, since OLM then falls back to its only channel.
+func isDefaultPresent(pkg registry.PackageManifest, manifestResult validator.ManifestResult) (bool, validator.ManifestResult) {
+	if pkg.DefaultChannelName == "" {
+		if len(pkg.Channels) > 1 {
+			manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidDefaultChannel(fmt.Sprintf("Warning: default channel not found in %s package manifest, which declares %d channels", pkg.PackageName, len(pkg.Channels)), pkg.PackageName))
+		} else {
 			manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidDefaultChannel(fmt.Sprintf("Warning: default channel not found in %s package manifest", pkg.PackageName), pkg.PackageName))
+		}
+		return true, manifestResult
+	}
+	for _, channel := range pkg.Channels {
+		if pkg.DefaultChannelName == channel.Name {
 			return true, manifestResult
-		} else if pkg.DefaultChannelName == channel.Name {
-			present = true
 		}
 	}
-	return present, manifestResult
+	return false, manifestResult
 }
@@ -15,6 +15,12 @@ import (
 	yamlForUnmarshalStrict "sigs.k8s.io/yaml"
 )
 
+const (
+	bundleMetadataDirectory = "metadata"
+	dependenciesFileName    = "dependencies.yaml"
+	propertiesFileName      = "properties.yaml"
+)
+
 // Manifest represents files in the operator manifest.
 type Manifest struct {
 	Name string
@@ -29,42 +35,46 @@ type ManifestBundle struct {
 	Version string
 	// List of CustomResourceDefinition file names inside the bundle.
 	CRDs []string
+	// CRDAPIVersions maps each CustomResourceDefinition file name to the
+	// apiextensions apiVersion it uses.
+	CRDAPIVersions map[string]string
 	// CSV file name in the bundle.
 	CSV string
+	// Dependencies is the file name of `metadata/dependencies.yaml`, if any.
+	Dependencies string
+	// Properties is the file name of `metadata/properties.yaml`, if any.
+	Properties string
 }
 
-// getFileType identifies the file type and returns it as a string.
-func getFileType(filePath string) (string, error) {
+// getFileType identifies the file type and returns it as a string, along with
+// the apiVersion of the file. Package yaml files have no apiVersion.
+func getFileType(filePath string) (string, string, error) {
 
 	rawYaml, err := ioutil.ReadFile(filePath)
 	if err != nil {
-		return "", fmt.Errorf("Error in reading %s file", filePath)
+		return "", "", fmt.Errorf("Error in reading %s file", filePath)
 	}
 	pkg := registry.PackageManifest{}
 
 	if checkFileTypeWithUnmarshalStrict(rawYaml, &pkg) {
-		return "Package", nil
+		return "Package", "", nil
 	}
 
-	kind, err := getKindFromFileBytes(rawYaml, filePath)
-	if err != nil {
-		return "", err
-	}
-	return kind, nil
+	return getTypeMetaFromFileBytes(rawYaml, filePath)
 }
 
-func getKindFromFileBytes(rawYaml []byte, filePath string) (string, error) {
+func getTypeMetaFromFileBytes(rawYaml []byte, filePath string) (string, string, error) {
 	u := unstructured.Unstructured{}
 	r := bytes.NewReader(rawYaml)
 	dec := yaml.NewYAMLOrJSONDecoder(r, 8)
 	// There is only one YAML doc if there are no more bytes to be read or EOF
 	// is hit.
 	if err := dec.Decode(&u); err == nil && r.Len() != 0 {
-		return "", fmt.Errorf("error getting TypeMeta from bytes: more than one manifest in bytes")
+		return "", "", fmt.Errorf("error getting TypeMeta from bytes: more than one manifest in bytes")
 	} else if err != nil && err != io.EOF {
-		return "", fmt.Errorf("error getting TypeMeta from bytes")
+		return "", "", fmt.Errorf("error getting TypeMeta from bytes")
 	}
-	return u.GetKind(), nil
+	return u.GetKind(), u.GetAPIVersion(), nil
 }
 
 func checkFileTypeWithUnmarshalStrict(rawYaml []byte, obj interface{}) bool {
@@ -95,13 +105,33 @@ func ParseDir(manifestDirectory string) (Manifest, validator.ManifestResult) {
 		}
 		// create a manifest bundle for each version in the manifest
 		if f.IsDir() && path != manifestDirectory {
+			// `metadata` directories hold bundle metadata, not a bundle.
+			if f.Name() == bundleMetadataDirectory {
+				return nil
+			}
 			if _, ok := manifest.Bundle[path]; !ok {
 				bundle := ManifestBundle{}
 				bundle.Version = f.Name()
 				manifest.Bundle[path] = bundle
 			}
 		} else if !f.IsDir() {
-			fileType, err := getFileType(path)
+			if filepath.Base(filepath.Dir(path)) == bundleMetadataDirectory {
+				bundlePath := filepath.Dir(filepath.Dir(path))
+				bundleObj, ok := manifest.Bundle[bundlePath]
+				if !ok {
+					manifestResult.Errors = append(manifestResult.Errors, validator.InvalidManifestStructure(fmt.Sprintf("Error: %s file at %s path does not align with the operator manifest format", f.Name(), path)))
+					return nil
+				}
+				switch f.Name() {
+				case dependenciesFileName:
+					bundleObj.Dependencies = path
+				case propertiesFileName:
+					bundleObj.Properties = path
+				}
+				manifest.Bundle[bundlePath] = bundleObj
+				return nil
+			}
+			fileType, apiVersion, err := getFileType(path)
 			if err != nil {
 				updateErr := fmt.Sprintf("Error: %s file may not be of ClusterServiceVersion, CustomResourceDefinition, or Package yaml type. If it is supposed to be ClusterServiceVersion or CustomResourceDefinition type, make sure the TypeMeta is correctly defined. If this is a package yaml, instead, make sure it follows the PackageManifest type definition", path)
 				manifestResult.Errors = append(manifestResult.Errors, validator.InvalidManifestStructure(updateErr))
@@ -125,8 +155,16 @@ func ParseDir(manifestDirectory string) (Manifest, validator.ManifestResult) {
 					return nil
 				}
 			case "CustomResourceDefinition":
+				if apiVersion != crdAPIVersionV1beta1 && apiVersion != crdAPIVersionV1 {
+					manifestResult.Errors = append(manifestResult.Errors, validator.InvalidManifestStructure(fmt.Sprintf("Error: %s file at %s path uses unsupported CustomResourceDefinition apiVersion `%s`", f.Name(), path, apiVersion)))
+					return nil
+				}
 				if bundleObj, ok := manifest.Bundle[directoryPath]; ok {
 					bundleObj.CRDs = append(bundleObj.CRDs, path)
+					if bundleObj.CRDAPIVersions == nil {
+						bundleObj.CRDAPIVersions = make(map[string]string)
+					}
+					bundleObj.CRDAPIVersions[path] = apiVersion
 					manifest.Bundle[directoryPath] = bundleObj
 				} else {
 					manifestResult.Errors = append(manifestResult.Errors, validator.InvalidManifestStructure(fmt.Sprintf("Error: %s file at %s path does not align with the operator manifest format", f.Name(), path)))
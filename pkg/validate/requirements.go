@@ -0,0 +1,81 @@
+package validate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate/validator"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// validateRequiredAPIs checks that the required CRDs and API services of the
+// CSV are well-formed and warns about APIs the CSV both requires and owns.
+func validateRequiredAPIs(csv v1alpha1.ClusterServiceVersion, manifestResult validator.ManifestResult) validator.ManifestResult {
+	owned, _ := getProvidedAPIs(csv, validator.ManifestResult{})
+	required, manifestResult := getRequiredAPIs(csv, manifestResult)
+	for _, gvk := range required {
+		if _, ok := owned[gvk]; ok {
+			manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidCSV(fmt.Sprintf("Warning: %s csv both requires and owns %v", csv.GetName(), gvk)))
+		}
+	}
+	return manifestResult
+}
+
+// getRequiredAPIs returns the GVKs of the well-formed required CRDs and API
+// services of the CSV, reporting malformed entries in manifestResult.
+func getRequiredAPIs(csv v1alpha1.ClusterServiceVersion, manifestResult validator.ManifestResult) ([]schema.GroupVersionKind, validator.ManifestResult) {
+	var required []schema.GroupVersionKind
+	for _, crd := range csv.Spec.CustomResourceDefinitions.Required {
+		parts := strings.SplitN(crd.Name, ".", 2)
+		if len(parts) < 2 || len(validation.IsDNS1123Subdomain(crd.Name)) != 0 {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidCSV(fmt.Sprintf("Error: required crd name `%s` in %s csv is not of the form `<plural>.<group>`", crd.Name, csv.GetName())))
+			continue
+		}
+		gvk := schema.GroupVersionKind{Group: parts[1], Version: crd.Version, Kind: crd.Kind}
+		if checkRequiredGVK(csv, "crd", crd.Name, gvk, &manifestResult) {
+			required = append(required, gvk)
+		}
+	}
+	for _, api := range csv.Spec.APIServiceDefinitions.Required {
+		gvk := schema.GroupVersionKind{Group: api.Group, Version: api.Version, Kind: api.Kind}
+		if len(validation.IsDNS1123Subdomain(api.Group)) != 0 {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidCSV(fmt.Sprintf("Error: required api service `%s` in %s csv has invalid group `%s`", api.Name, csv.GetName(), api.Group)))
+			continue
+		}
+		if checkRequiredGVK(csv, "api service", api.Name, gvk, &manifestResult) {
+			required = append(required, gvk)
+		}
+	}
+	return required, manifestResult
+}
+
+func checkRequiredGVK(csv v1alpha1.ClusterServiceVersion, kind, name string, gvk schema.GroupVersionKind, manifestResult *validator.ManifestResult) bool {
+	valid := true
+	if len(validation.IsDNS1035Label(gvk.Version)) != 0 {
+		manifestResult.Errors = append(manifestResult.Errors, validator.InvalidCSV(fmt.Sprintf("Error: required %s `%s` in %s csv has invalid version `%s`", kind, name, csv.GetName(), gvk.Version)))
+		valid = false
+	}
+	if gvk.Kind == "" {
+		manifestResult.Errors = append(manifestResult.Errors, validator.InvalidCSV(fmt.Sprintf("Error: required %s `%s` in %s csv has no kind", kind, name, csv.GetName())))
+		valid = false
+	}
+	return valid
+}
+
+// resolveRequiredAPIs reports required APIs of the CSV that no operator in
+// catalog, nor the CSV itself, provides.
+func resolveRequiredAPIs(csv v1alpha1.ClusterServiceVersion, catalog Catalog, manifestResult validator.ManifestResult) validator.ManifestResult {
+	owned, _ := getProvidedAPIs(csv, validator.ManifestResult{})
+	required, _ := getRequiredAPIs(csv, validator.ManifestResult{})
+	for _, gvk := range required {
+		if _, ok := owned[gvk]; ok {
+			continue
+		}
+		if !catalog.Provides(gvk) {
+			manifestResult.Errors = append(manifestResult.Errors, validator.UnsatisfiedRequirement(fmt.Sprintf("Error: %v required by %s csv is not provided by any operator in catalog %s", gvk, csv.GetName(), catalog.Root), gvk))
+		}
+	}
+	return manifestResult
+}
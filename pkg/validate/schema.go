@@ -0,0 +1,428 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate/validator"
+	"github.com/ghodss/yaml"
+)
+
+// knownSchemaExtensions lists the `x-kubernetes-*` extensions understood by
+// the API server.
+var knownSchemaExtensions = []string{
+	"x-kubernetes-preserve-unknown-fields",
+	"x-kubernetes-embedded-resource",
+	"x-kubernetes-int-or-string",
+	"x-kubernetes-list-type",
+	"x-kubernetes-list-map-keys",
+	"x-kubernetes-map-type",
+}
+
+// junctorDisallowedFields lists the fields a structural schema may not set
+// inside `allOf`, `anyOf`, `oneOf` or `not`.
+var junctorDisallowedFields = []string{"type", "description", "default", "additionalProperties", "nullable"}
+
+// readRawCRD reads a CRD file into its generic JSON form. It is used for the
+// fields the vendored apiextensions types do not model, such as the
+// `x-kubernetes-*` schema extensions.
+func readRawCRD(pathCRD string) (map[string]interface{}, validator.Error) {
+	rawYaml, err := ioutil.ReadFile(pathCRD)
+	if err != nil {
+		return nil, validator.IOError(fmt.Sprintf("Error in reading %s file:   #%s ", pathCRD, err), pathCRD)
+	}
+	rawJson, err := yaml.YAMLToJSON(rawYaml)
+	if err != nil {
+		return nil, validator.InvalidParse(fmt.Sprintf("Error in converting to JSON for %s file:   #%s ", pathCRD, err), pathCRD)
+	}
+	raw := map[string]interface{}{}
+	if err := json.Unmarshal(rawJson, &raw); err != nil {
+		return nil, validator.InvalidParse(fmt.Sprintf("Error parsing %s file:   #%s ", pathCRD, err), pathCRD)
+	}
+	return raw, validator.Error{}
+}
+
+// getRawSchemas returns every openAPIV3Schema of a raw CRD keyed by its field
+// path.
+func getRawSchemas(raw map[string]interface{}) map[string]map[string]interface{} {
+	schemas := map[string]map[string]interface{}{}
+	spec, _ := raw["spec"].(map[string]interface{})
+	if validation, ok := spec["validation"].(map[string]interface{}); ok {
+		if schema, ok := validation["openAPIV3Schema"].(map[string]interface{}); ok {
+			schemas["spec.validation.openAPIV3Schema"] = schema
+		}
+	}
+	versions, _ := spec["versions"].([]interface{})
+	for i, v := range versions {
+		version, _ := v.(map[string]interface{})
+		if versionSchema, ok := version["schema"].(map[string]interface{}); ok {
+			if schema, ok := versionSchema["openAPIV3Schema"].(map[string]interface{}); ok {
+				schemas[fmt.Sprintf("spec.versions[%d].schema.openAPIV3Schema", i)] = schema
+			}
+		}
+	}
+	return schemas
+}
+
+// rawCRDInspect runs the checks that need the generic JSON form of the CRD
+// file: structural schema checks and, for apiextensions.k8s.io/v1 CRDs, the
+// v1-specific rules. The API server rejects non-structural v1 CRDs, so
+// structural findings are always errors for them. Unknown extensions are
+// dropped rather than rejected by the API server and keep the configured
+// severity.
+func rawCRDInspect(pathCRD string, options Options, manifestResult validator.ManifestResult) validator.ManifestResult {
+	raw, err := readRawCRD(pathCRD)
+	if err != (validator.Error{}) {
+		manifestResult.Errors = append(manifestResult.Errors, err)
+		return manifestResult
+	}
+	severity := options.StructuralSchemaSeverity
+	if raw["apiVersion"] == crdAPIVersionV1 {
+		manifestResult = v1CRDInspect(raw, manifestResult)
+		severity = SeverityError
+	}
+	manifestResult = structuralSchemaInspect(raw, severity, manifestResult)
+	return schemaExtensionsInspect(raw, options.StructuralSchemaSeverity, manifestResult)
+}
+
+// schemaExtensionsInspect reports `x-kubernetes-*` extensions in the schemas
+// of the raw CRD that the API server does not know.
+func schemaExtensionsInspect(raw map[string]interface{}, severity Severity, manifestResult validator.ManifestResult) validator.ManifestResult {
+	schemas := getRawSchemas(raw)
+	var paths []string
+	for path := range schemas {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		for _, finding := range checkSchemaExtensions(schemas[path], path) {
+			manifestResult = appendWithSeverity(manifestResult, severity, finding)
+		}
+	}
+	return manifestResult
+}
+
+func checkSchemaExtensions(node map[string]interface{}, path string) (findings []validator.Error) {
+	for _, key := range sortedFieldNames(node) {
+		if strings.HasPrefix(key, "x-kubernetes-") && !containsStrict(knownSchemaExtensions, key) {
+			field := path + "." + key
+			findings = append(findings, validator.InvalidSchema(fmt.Sprintf("%s: unknown extension; the API server drops it", field), field, key))
+		}
+	}
+
+	properties, _ := node["properties"].(map[string]interface{})
+	for _, name := range sortedFieldNames(properties) {
+		if child, ok := properties[name].(map[string]interface{}); ok {
+			findings = append(findings, checkSchemaExtensions(child, fmt.Sprintf("%s.properties[%s]", path, name))...)
+		}
+	}
+	for _, field := range []string{"items", "additionalProperties", "not"} {
+		if child, ok := node[field].(map[string]interface{}); ok {
+			findings = append(findings, checkSchemaExtensions(child, path+"."+field)...)
+		}
+	}
+	for _, junctor := range []string{"allOf", "anyOf", "oneOf"} {
+		list, _ := node[junctor].([]interface{})
+		for i, entry := range list {
+			if child, ok := entry.(map[string]interface{}); ok {
+				findings = append(findings, checkSchemaExtensions(child, fmt.Sprintf("%s.%s[%d]", path, junctor, i))...)
+			}
+		}
+	}
+	return findings
+}
+
+// v1CRDInspect checks the rules specific to apiextensions.k8s.io/v1 CRDs.
+func v1CRDInspect(raw map[string]interface{}, manifestResult validator.ManifestResult) validator.ManifestResult {
+	spec, _ := raw["spec"].(map[string]interface{})
+	if spec["preserveUnknownFields"] == true {
+		manifestResult.Errors = append(manifestResult.Errors, validator.InvalidSchema("Error: spec.preserveUnknownFields: must be false for apiextensions.k8s.io/v1 CRDs; use `x-kubernetes-preserve-unknown-fields` in the schema instead", "spec.preserveUnknownFields", true))
+	}
+	for _, field := range []string{"version", "validation", "subresources", "additionalPrinterColumns"} {
+		if _, ok := spec[field]; ok {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidSchema(fmt.Sprintf("Error: spec.%s: not supported by apiextensions.k8s.io/v1 CRDs; set it per version in `spec.versions` instead", field), "spec."+field, nil))
+		}
+	}
+	versions, _ := spec["versions"].([]interface{})
+	if len(versions) == 0 {
+		manifestResult.Errors = append(manifestResult.Errors, validator.InvalidSchema("Error: spec.versions: must not be empty for apiextensions.k8s.io/v1 CRDs", "spec.versions", nil))
+	}
+	for i, v := range versions {
+		version, _ := v.(map[string]interface{})
+		versionSchema, _ := version["schema"].(map[string]interface{})
+		if _, ok := versionSchema["openAPIV3Schema"].(map[string]interface{}); !ok {
+			field := fmt.Sprintf("spec.versions[%d].schema.openAPIV3Schema", i)
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidSchema(fmt.Sprintf("Error: %s: a schema is required for every version of an apiextensions.k8s.io/v1 CRD", field), field, version["name"]))
+		}
+	}
+	return manifestResult
+}
+
+// perVersionCRDFields pairs the per-version fields of a CRD with the
+// top-level v1beta1 fields they replace.
+var perVersionCRDFields = [][2]string{
+	{"schema", "validation"},
+	{"subresources", "subresources"},
+	{"additionalPrinterColumns", "additionalPrinterColumns"},
+}
+
+// convertV1CRDJSON rewrites the fields of an apiextensions.k8s.io/v1 CRD whose
+// layout differs from v1beta1, so that it can be decoded into the v1beta1
+// type. As in the API server's conversion, per-version fields set to the same
+// value for every version are moved to the top-level field, since v1beta1
+// validation rejects identical per-version values.
+func convertV1CRDJSON(rawJson []byte) ([]byte, error) {
+	raw := map[string]interface{}{}
+	if err := json.Unmarshal(rawJson, &raw); err != nil {
+		return nil, err
+	}
+	spec, _ := raw["spec"].(map[string]interface{})
+	versions, _ := spec["versions"].([]interface{})
+	for _, v := range versions {
+		version, _ := v.(map[string]interface{})
+		columns, _ := version["additionalPrinterColumns"].([]interface{})
+		for _, c := range columns {
+			if column, ok := c.(map[string]interface{}); ok {
+				if jsonPath, ok := column["jsonPath"]; ok {
+					column["JSONPath"] = jsonPath
+					delete(column, "jsonPath")
+				}
+			}
+		}
+	}
+	for _, fields := range perVersionCRDFields {
+		field, topLevelField := fields[0], fields[1]
+		if len(versions) == 0 || !hasIdenticalVersionField(versions, field) {
+			continue
+		}
+		first, _ := versions[0].(map[string]interface{})
+		spec[topLevelField] = first[field]
+		for _, v := range versions {
+			version, _ := v.(map[string]interface{})
+			delete(version, field)
+		}
+	}
+	if conversion, ok := spec["conversion"].(map[string]interface{}); ok {
+		if webhook, ok := conversion["webhook"].(map[string]interface{}); ok {
+			conversion["webhookClientConfig"] = webhook["clientConfig"]
+			conversion["conversionReviewVersions"] = webhook["conversionReviewVersions"]
+			delete(conversion, "webhook")
+		}
+	}
+	return json.Marshal(raw)
+}
+
+// hasIdenticalVersionField returns true if every entry of the raw
+// `spec.versions` list sets field to the same value.
+func hasIdenticalVersionField(versions []interface{}, field string) bool {
+	first, _ := versions[0].(map[string]interface{})
+	value, ok := first[field]
+	if !ok {
+		return false
+	}
+	for _, v := range versions[1:] {
+		version, _ := v.(map[string]interface{})
+		if other, ok := version[field]; !ok || !reflect.DeepEqual(value, other) {
+			return false
+		}
+	}
+	return true
+}
+
+// structuralSchemaInspect reports the parts of each schema in the raw CRD that
+// are not structural, as required by apiextensions.k8s.io/v1.
+func structuralSchemaInspect(raw map[string]interface{}, severity Severity, manifestResult validator.ManifestResult) validator.ManifestResult {
+	schemas := getRawSchemas(raw)
+	var paths []string
+	for path := range schemas {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		for _, finding := range checkStructural(schemas[path], path, true, false) {
+			manifestResult = appendWithSeverity(manifestResult, severity, finding)
+		}
+	}
+	return manifestResult
+}
+
+// checkStructural walks a raw schema node and returns a finding for every
+// violation of the structural schema rules.
+func checkStructural(node map[string]interface{}, path string, isRoot, inJunctor bool) (findings []validator.Error) {
+	report := func(field, detail string, value interface{}) {
+		findings = append(findings, validator.InvalidSchema(fmt.Sprintf("%s: %s", field, detail), field, value))
+	}
+
+	for _, key := range sortedFieldNames(node) {
+		if !strings.HasPrefix(key, "x-kubernetes-") {
+			continue
+		}
+		if !containsStrict(knownSchemaExtensions, key) {
+			continue
+		}
+		if inJunctor {
+			report(path+"."+key, "extensions must not be set inside allOf, anyOf, oneOf or not", node[key])
+		}
+	}
+
+	nodeType, _ := node["type"].(string)
+	preserveUnknown := node["x-kubernetes-preserve-unknown-fields"] == true
+	intOrString := node["x-kubernetes-int-or-string"] == true
+
+	if inJunctor {
+		for _, field := range junctorDisallowedFields {
+			if _, ok := node[field]; ok {
+				report(path+"."+field, "must not be set inside allOf, anyOf, oneOf or not", node[field])
+			}
+		}
+	} else {
+		switch {
+		case isRoot && nodeType != "object":
+			report(path+".type", "must be `object` at the root of the schema", nodeType)
+		case nodeType == "" && !preserveUnknown && !intOrString:
+			report(path+".type", "must not be empty; set `type` or `x-kubernetes-preserve-unknown-fields`/`x-kubernetes-int-or-string`", nil)
+		}
+	}
+
+	if value, ok := node["x-kubernetes-preserve-unknown-fields"]; ok && value != true {
+		report(path+".x-kubernetes-preserve-unknown-fields", "must be true or undefined", value)
+	}
+	if intOrString && nodeType != "" {
+		report(path+".type", "must be empty when `x-kubernetes-int-or-string` is true", nodeType)
+	}
+	if node["x-kubernetes-embedded-resource"] == true {
+		if nodeType != "object" {
+			report(path+".type", "must be `object` when `x-kubernetes-embedded-resource` is true", nodeType)
+		}
+		if _, ok := node["properties"]; !ok && !preserveUnknown {
+			report(path+".x-kubernetes-embedded-resource", "requires `properties` or `x-kubernetes-preserve-unknown-fields`", true)
+		}
+	}
+	if listType, ok := node["x-kubernetes-list-type"]; ok {
+		if nodeType != "array" {
+			report(path+".x-kubernetes-list-type", "may only be used on arrays", listType)
+		}
+		if !containsStrict([]string{"atomic", "set", "map"}, fmt.Sprint(listType)) {
+			report(path+".x-kubernetes-list-type", "must be one of atomic, set or map", listType)
+		}
+	}
+	if keys, ok := node["x-kubernetes-list-map-keys"]; ok && node["x-kubernetes-list-type"] != "map" {
+		report(path+".x-kubernetes-list-map-keys", "may only be used with `x-kubernetes-list-type: map`", keys)
+	}
+	if mapType, ok := node["x-kubernetes-map-type"]; ok {
+		if nodeType != "object" {
+			report(path+".x-kubernetes-map-type", "may only be used on objects", mapType)
+		}
+		if !containsStrict([]string{"granular", "atomic"}, fmt.Sprint(mapType)) {
+			report(path+".x-kubernetes-map-type", "must be one of granular or atomic", mapType)
+		}
+	}
+
+	properties, _ := node["properties"].(map[string]interface{})
+	if additional, ok := node["additionalProperties"]; ok && properties != nil && additional != false {
+		report(path+".additionalProperties", "must not be set together with `properties`", additional)
+	}
+	if isRoot && properties != nil {
+		if metadata, ok := properties["metadata"].(map[string]interface{}); ok {
+			metadataProperties, _ := metadata["properties"].(map[string]interface{})
+			for _, name := range sortedFieldNames(metadataProperties) {
+				if name != "name" && name != "generateName" {
+					report(path+".properties[metadata].properties["+name+"]", "only `name` and `generateName` may be specified for metadata", name)
+				}
+			}
+		}
+	}
+
+	for _, name := range sortedFieldNames(properties) {
+		if childNode, ok := properties[name].(map[string]interface{}); ok {
+			findings = append(findings, checkStructural(childNode, fmt.Sprintf("%s.properties[%s]", path, name), false, inJunctor)...)
+		}
+	}
+	switch items := node["items"].(type) {
+	case map[string]interface{}:
+		findings = append(findings, checkStructural(items, path+".items", false, inJunctor)...)
+	case []interface{}:
+		report(path+".items", "must be a single schema, not a list of schemas", nil)
+	}
+	if additional, ok := node["additionalProperties"].(map[string]interface{}); ok {
+		findings = append(findings, checkStructural(additional, path+".additionalProperties", false, inJunctor)...)
+	}
+
+	for _, junctor := range []string{"allOf", "anyOf", "oneOf"} {
+		list, _ := node[junctor].([]interface{})
+		for i, entry := range list {
+			if intOrString && isIntOrStringJunctorEntry(junctor, i, list) {
+				continue
+			}
+			if entryNode, ok := entry.(map[string]interface{}); ok {
+				entryPath := fmt.Sprintf("%s.%s[%d]", path, junctor, i)
+				findings = append(findings, checkStructural(entryNode, entryPath, false, true)...)
+				findings = append(findings, checkJunctorProperties(entryNode, properties, entryPath)...)
+			}
+		}
+	}
+	if not, ok := node["not"].(map[string]interface{}); ok {
+		findings = append(findings, checkStructural(not, path+".not", false, true)...)
+		findings = append(findings, checkJunctorProperties(not, properties, path+".not")...)
+	}
+	return findings
+}
+
+// isIntOrStringJunctorEntry returns true if the i-th entry of the junctor list
+// is part of the form Kubernetes allows under `x-kubernetes-int-or-string`:
+// either `anyOf: [{type: integer}, {type: string}]`, or the same `anyOf` as
+// the first entry of `allOf`.
+func isIntOrStringJunctorEntry(junctor string, i int, list []interface{}) bool {
+	switch junctor {
+	case "anyOf":
+		return isIntOrStringAnyOf(list)
+	case "allOf":
+		first, _ := list[0].(map[string]interface{})
+		anyOf, _ := first["anyOf"].([]interface{})
+		return i == 0 && len(first) == 1 && isIntOrStringAnyOf(anyOf)
+	}
+	return false
+}
+
+// isIntOrStringAnyOf returns true if list is exactly
+// `[{type: integer}, {type: string}]`.
+func isIntOrStringAnyOf(list []interface{}) bool {
+	if len(list) != 2 {
+		return false
+	}
+	for i, expected := range []string{"integer", "string"} {
+		entry, _ := list[i].(map[string]interface{})
+		if len(entry) != 1 || entry["type"] != expected {
+			return false
+		}
+	}
+	return true
+}
+
+// checkJunctorProperties reports properties specified inside a junctor that
+// are not also specified outside of it.
+func checkJunctorProperties(junctor map[string]interface{}, outer map[string]interface{}, path string) (findings []validator.Error) {
+	properties, _ := junctor["properties"].(map[string]interface{})
+	for _, name := range sortedFieldNames(properties) {
+		if _, ok := outer[name]; !ok {
+			field := fmt.Sprintf("%s.properties[%s]", path, name)
+			findings = append(findings, validator.InvalidSchema(fmt.Sprintf("%s: must also be specified outside of allOf, anyOf, oneOf or not", field), field, name))
+		}
+	}
+	return findings
+}
+
+// sortedFieldNames returns the field names of a raw schema node in sorted
+// order, so that findings are reported in the same order on every run.
+func sortedFieldNames(node map[string]interface{}) []string {
+	names := make([]string, 0, len(node))
+	for name := range node {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,191 @@
+package validate
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate/validator"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+)
+
+// checkBreakingCRDChanges compares the CRDs of every CSV in the manifest with
+// the CRDs of the CSV it replaces, and reports backward-incompatible changes.
+// crdsByBundle holds the CRDs of each bundle; bundles whose CRDs could not be
+// parsed are skipped.
+func checkBreakingCRDChanges(csvsByBundle map[string]v1alpha1.ClusterServiceVersion, crdsByBundle map[string]map[string]v1beta1.CustomResourceDefinition, manifestResult validator.ManifestResult) validator.ManifestResult {
+	bundleByCSVName := map[string]string{}
+	for bundlePath, csv := range csvsByBundle {
+		bundleByCSVName[csv.GetName()] = bundlePath
+	}
+
+	var bundlePaths []string
+	for bundlePath := range csvsByBundle {
+		bundlePaths = append(bundlePaths, bundlePath)
+	}
+	sort.Strings(bundlePaths)
+	for _, bundlePath := range bundlePaths {
+		csv := csvsByBundle[bundlePath]
+		oldBundlePath, ok := bundleByCSVName[csv.Spec.Replaces]
+		if csv.Spec.Replaces == "" || !ok {
+			continue
+		}
+		newCRDs, newOK := crdsByBundle[bundlePath]
+		oldCRDs, oldOK := crdsByBundle[oldBundlePath]
+		if !newOK || !oldOK {
+			continue
+		}
+		for _, name := range sortedCRDNames(newCRDs) {
+			oldCRD, ok := oldCRDs[name]
+			if !ok {
+				continue
+			}
+			for _, change := range diffCRDs(oldCRD, newCRDs[name]) {
+				manifestResult.Errors = append(manifestResult.Errors, validator.InvalidBundle(fmt.Sprintf("Error: breaking change to crd `%s` between %s csv and %s csv it replaces: %s", name, csv.GetName(), csv.Spec.Replaces, change), name))
+			}
+		}
+	}
+	return manifestResult
+}
+
+// diffCRDs returns a description of every backward-incompatible change from
+// oldCRD to newCRD: removed served versions, and schema changes within each
+// version served by both.
+func diffCRDs(oldCRD, newCRD v1beta1.CustomResourceDefinition) []string {
+	var changes []string
+	newServed := getServedVersions(newCRD)
+	for _, version := range getServedVersions(oldCRD) {
+		if !isStringPresent(newServed, version) {
+			changes = append(changes, fmt.Sprintf("served version `%s` removed", version))
+			continue
+		}
+		oldSchema := getCRDSchema(oldCRD, version)
+		newSchema := getCRDSchema(newCRD, version)
+		if oldSchema == nil || newSchema == nil {
+			continue
+		}
+		for _, change := range diffSchemas(oldSchema, newSchema, "") {
+			changes = append(changes, fmt.Sprintf("version `%s`: %s", version, change))
+		}
+	}
+	return changes
+}
+
+// diffSchemas recursively compares two schemas and returns the changes that
+// may reject objects that were valid under oldSchema.
+func diffSchemas(oldSchema, newSchema *v1beta1.JSONSchemaProps, path string) []string {
+	var changes []string
+	at := path
+	if at == "" {
+		at = "<root>"
+	}
+
+	if oldSchema.Type != newSchema.Type && !isWideningType(oldSchema.Type, newSchema.Type) {
+		changes = append(changes, fmt.Sprintf("`%s` type changed from `%s` to `%s`", at, oldSchema.Type, newSchema.Type))
+	}
+	if oldSchema.Format != newSchema.Format && newSchema.Format != "" {
+		changes = append(changes, fmt.Sprintf("`%s` format changed from `%s` to `%s`", at, oldSchema.Format, newSchema.Format))
+	}
+
+	oldRequired := map[string]struct{}{}
+	for _, name := range oldSchema.Required {
+		oldRequired[name] = struct{}{}
+	}
+	for _, name := range newSchema.Required {
+		if _, ok := oldRequired[name]; !ok {
+			changes = append(changes, fmt.Sprintf("`%s` is newly required", joinSchemaPath(path, name)))
+		}
+	}
+
+	if len(newSchema.Enum) != 0 {
+		if len(oldSchema.Enum) == 0 {
+			changes = append(changes, fmt.Sprintf("`%s` is newly restricted to an enum", at))
+		} else {
+			newValues := map[string]struct{}{}
+			for _, value := range newSchema.Enum {
+				newValues[string(value.Raw)] = struct{}{}
+			}
+			for _, value := range oldSchema.Enum {
+				if _, ok := newValues[string(value.Raw)]; !ok {
+					changes = append(changes, fmt.Sprintf("`%s` enum value %s removed", at, string(value.Raw)))
+				}
+			}
+		}
+	}
+	if newSchema.Pattern != "" && newSchema.Pattern != oldSchema.Pattern {
+		changes = append(changes, fmt.Sprintf("`%s` pattern changed from `%s` to `%s`", at, oldSchema.Pattern, newSchema.Pattern))
+	}
+	changes = append(changes, diffBounds(at, "maximum", oldSchema.Maximum, newSchema.Maximum, true)...)
+	changes = append(changes, diffBounds(at, "minimum", oldSchema.Minimum, newSchema.Minimum, false)...)
+	changes = append(changes, diffIntBounds(at, "maxLength", oldSchema.MaxLength, newSchema.MaxLength, true)...)
+	changes = append(changes, diffIntBounds(at, "minLength", oldSchema.MinLength, newSchema.MinLength, false)...)
+	changes = append(changes, diffIntBounds(at, "maxItems", oldSchema.MaxItems, newSchema.MaxItems, true)...)
+	changes = append(changes, diffIntBounds(at, "minItems", oldSchema.MinItems, newSchema.MinItems, false)...)
+
+	// A schema without properties accepts any field, so only compare
+	// properties when the old schema declared them.
+	if len(oldSchema.Properties) != 0 {
+		var names []string
+		for name := range oldSchema.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			oldProperty := oldSchema.Properties[name]
+			newProperty, ok := newSchema.Properties[name]
+			if !ok {
+				if len(newSchema.Properties) != 0 {
+					changes = append(changes, fmt.Sprintf("field `%s` removed", joinSchemaPath(path, name)))
+				}
+				continue
+			}
+			changes = append(changes, diffSchemas(&oldProperty, &newProperty, joinSchemaPath(path, name))...)
+		}
+	}
+	if oldSchema.Items != nil && oldSchema.Items.Schema != nil && newSchema.Items != nil && newSchema.Items.Schema != nil {
+		changes = append(changes, diffSchemas(oldSchema.Items.Schema, newSchema.Items.Schema, path+"[]")...)
+	}
+	return changes
+}
+
+// isWideningType returns true if every value of oldType is also a valid
+// newType, or if the new schema drops the type altogether.
+func isWideningType(oldType, newType string) bool {
+	return newType == "" || (oldType == "integer" && newType == "number")
+}
+
+func diffBounds(at, name string, oldBound, newBound *float64, isMax bool) []string {
+	if newBound == nil || (oldBound != nil && *oldBound == *newBound) {
+		return nil
+	}
+	if oldBound == nil || (isMax && *newBound < *oldBound) || (!isMax && *newBound > *oldBound) {
+		return []string{fmt.Sprintf("`%s` %s tightened to %v", at, name, *newBound)}
+	}
+	return nil
+}
+
+func diffIntBounds(at, name string, oldBound, newBound *int64, isMax bool) []string {
+	if newBound == nil || (oldBound != nil && *oldBound == *newBound) {
+		return nil
+	}
+	if oldBound == nil || (isMax && *newBound < *oldBound) || (!isMax && *newBound > *oldBound) {
+		return []string{fmt.Sprintf("`%s` %s tightened to %d", at, name, *newBound)}
+	}
+	return nil
+}
+
+func joinSchemaPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func sortedCRDNames(crds map[string]v1beta1.CustomResourceDefinition) []string {
+	var names []string
+	for name := range crds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -8,12 +8,22 @@ import (
 )
 
 func Validate(v validator.Validator) (manifestResult validator.ManifestResult) {
-	fmt.Printf("\nRunning %s\n", v.Name())
-	fmt.Printf("Validating %s\n\n", v.FileName())
+	return runValidator(v, true)
+}
+
+// runValidator reads, unmarshals and validates the file of v. If report is
+// set, the findings are printed as they are found.
+func runValidator(v validator.Validator, report bool) (manifestResult validator.ManifestResult) {
+	if report {
+		fmt.Printf("\nRunning %s\n", v.Name())
+		fmt.Printf("Validating %s\n\n", v.FileName())
+	}
 	rawYaml, err := ioutil.ReadFile(v.FileName())
 	if err != nil {
 		manifestResult.Errors = append(manifestResult.Errors, validator.IOError(fmt.Sprintf("Error in reading %s file:   #%s ", v.FileName(), err), v.FileName()))
-		getErrorsFromManifestResult(manifestResult.Errors)
+		if report {
+			getErrorsFromManifestResult(manifestResult.Errors)
+		}
 		return
 	}
 
@@ -21,17 +31,27 @@ func Validate(v validator.Validator) (manifestResult validator.ManifestResult) {
 	unmarshalledObject, err := v.Unmarshal(rawYaml)
 	if err != nil {
 		manifestResult.Errors = append(manifestResult.Errors, validator.InvalidParse(fmt.Sprintf("Error unmarshalling YAML for %s file:  #%s ", v.FileName(), err), v.FileName()))
-		getErrorsFromManifestResult(manifestResult.Errors)
+		if report {
+			getErrorsFromManifestResult(manifestResult.Errors)
+		}
 		return
 	}
 
 	if err := v.AddObjects(unmarshalledObject); err != (validator.Error{}) {
 		manifestResult.Errors = append(manifestResult.Errors, err)
-		getErrorsFromManifestResult(manifestResult.Errors)
+		if report {
+			getErrorsFromManifestResult(manifestResult.Errors)
+		}
 		return // TODO: update when 'AddObjects' returns an actual error.
 	}
 
+	manifestResult.Name = v.FileName()
 	for _, errorLog := range v.Validate() {
+		manifestResult.Errors = append(manifestResult.Errors, errorLog.Errors...)
+		manifestResult.Warnings = append(manifestResult.Warnings, errorLog.Warnings...)
+		if !report {
+			continue
+		}
 
 		getErrorsFromManifestResult(errorLog.Warnings)
 
@@ -59,9 +79,12 @@ func assertTypeToGetValue(v interface{}) {
 	}
 }
 
-func validateBundle(manifest Manifest) []validator.ManifestResult {
-	v := &BundleValidator{Manifest: manifest}
+func validateBundle(manifest Manifest, options Options, report bool) []validator.ManifestResult {
+	v := &BundleValidator{Manifest: manifest, options: options}
 	manifestResult := v.Validate()
+	if !report {
+		return manifestResult
+	}
 	for _, errorLog := range manifestResult {
 		fmt.Printf("\nValidating `%s` Manifest\n", errorLog.Name)
 		fmt.Println()
@@ -80,17 +103,23 @@ func validateBundle(manifest Manifest) []validator.ManifestResult {
 	return manifestResult
 }
 
-func parseManifestDirectory(manifestDirectory string) (Manifest, []validator.ManifestResult) {
+func parseManifestDirectory(manifestDirectory string, report bool) (Manifest, []validator.ManifestResult) {
 	manifestResultList := []validator.ManifestResult{}
-	fmt.Printf("Parsing `%s` operator manifest\n\n", manifestDirectory)
+	if report {
+		fmt.Printf("Parsing `%s` operator manifest\n\n", manifestDirectory)
+	}
 	manifest, manifestResultFromDirectoryParse := ParseDir(manifestDirectory)
 
 	if len(manifestResultFromDirectoryParse.Errors) != 0 || len(manifestResultFromDirectoryParse.Warnings) != 0 {
 		manifestResultList = append(manifestResultList, manifestResultFromDirectoryParse)
-		getErrorsFromManifestResult(manifestResultFromDirectoryParse.Warnings)
+		if report {
+			getErrorsFromManifestResult(manifestResultFromDirectoryParse.Warnings)
+		}
 		if len(manifestResultFromDirectoryParse.Errors) != 0 {
-			getErrorsFromManifestResult(manifestResultFromDirectoryParse.Errors)
-			fmt.Printf("Invalid operator manifest structure for `%s`\n", manifestDirectory)
+			if report {
+				getErrorsFromManifestResult(manifestResultFromDirectoryParse.Errors)
+				fmt.Printf("Invalid operator manifest structure for `%s`\n", manifestDirectory)
+			}
 			return Manifest{}, manifestResultList
 		}
 	}
@@ -98,31 +127,62 @@ func parseManifestDirectory(manifestDirectory string) (Manifest, []validator.Man
 }
 
 func ValidateManifest(manifestDirectory string) []validator.ManifestResult {
+	return ValidateManifestWithOptions(manifestDirectory, DefaultOptions())
+}
+
+// ValidateManifestWithOptions validates the operator manifest at
+// manifestDirectory using the limits and policies in options, and prints a
+// report of the findings.
+func ValidateManifestWithOptions(manifestDirectory string, options Options) []validator.ManifestResult {
+	return validateManifest(manifestDirectory, options, true)
+}
+
+// validateManifest runs every check on the operator manifest at
+// manifestDirectory. If report is set, the findings and the channels of the
+// package are printed as they are found.
+func validateManifest(manifestDirectory string, options Options, report bool) []validator.ManifestResult {
 	// parse manifest directory
-	manifest, manifestResultList := parseManifestDirectory(manifestDirectory)
+	manifest, manifestResultList := parseManifestDirectory(manifestDirectory, report)
 	for _, manifestResult := range manifestResultList {
 		if len(manifestResult.Errors) != 0 {
 			return manifestResultList
 		}
 	}
 
-	var result []validator.ManifestResult
+	return validateParsedManifest(manifest, manifestResultList, options, report)
+}
+
+// validateParsedManifest runs every check on manifest, which was parsed with
+// the findings in parseResults.
+func validateParsedManifest(manifest Manifest, parseResults []validator.ManifestResult, options Options, report bool) []validator.ManifestResult {
+	result := parseResults
 	// validate individual bundle files
 	for _, bundle := range manifest.Bundle {
-		validators := []validator.Validator{&CSVValidator{fileName: bundle.CSV}}
+		validators := []validator.Validator{&CSVValidator{fileName: bundle.CSV, options: options}}
 		for _, crd := range bundle.CRDs {
-			validators = append(validators, &CRDValidator{fileName: crd})
+			if report {
+				fmt.Printf("\nFound %s CustomResourceDefinition at %s\n", bundle.CRDAPIVersions[crd], crd)
+			}
+			validators = append(validators, &CRDValidator{fileName: crd, options: options})
 		}
 		for _, validator := range validators {
-			result = append(result, Validate(validator))
+			result = append(result, runValidator(validator, report))
 		}
 	}
 	var pkgValidator validator.Validator
 	pkgValidator = &PackageValidator{fileName: manifest.Package}
-	Validate(pkgValidator)
+	result = append(result, runValidator(pkgValidator, report))
 
 	// validate bundle
-	validateBundle(manifest)
-	return []validator.ManifestResult{}
+	result = append(result, validateBundle(manifest, options, report)...)
+
+	// report channel membership
+	if !report {
+		return result
+	}
+	if graph, graphResult := BuildUpgradeGraph(manifest); len(graphResult.Errors) == 0 {
+		fmt.Printf("\n\n%s", ChannelReport(graph))
+	}
+	return result
 }
 
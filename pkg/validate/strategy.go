@@ -0,0 +1,95 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate/validator"
+	"github.com/ghodss/yaml"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+const installStrategyNameDeployment = "deployment"
+
+// strategyDetailsDeployment holds the fields of OLM's deployment install
+// strategy that are read by the validators. OLM's own type is not used as its
+// package does not build against the vendored client libraries.
+type strategyDetailsDeployment struct {
+	DeploymentSpecs    []strategyDeploymentSpec        `json:"deployments"`
+	Permissions        []strategyDeploymentPermissions `json:"permissions,omitempty"`
+	ClusterPermissions []strategyDeploymentPermissions `json:"clusterPermissions,omitempty"`
+}
+
+type strategyDeploymentSpec struct {
+	Name string         `json:"name"`
+	Spec deploymentSpec `json:"spec"`
+}
+
+type deploymentSpec struct {
+	Template corev1.PodTemplateSpec `json:"template"`
+}
+
+type strategyDeploymentPermissions struct {
+	ServiceAccountName string              `json:"serviceAccountName"`
+	Rules              []rbacv1.PolicyRule `json:"rules"`
+}
+
+// getStrategyDetails unmarshals the deployment install strategy of the CSV.
+func getStrategyDetails(csv v1alpha1.ClusterServiceVersion) (strategyDetailsDeployment, validator.Error) {
+	var strategy strategyDetailsDeployment
+	if csv.Spec.InstallStrategy.StrategyName != installStrategyNameDeployment {
+		return strategy, validator.InvalidCSV(fmt.Sprintf("Error: unsupported install strategy `%s` in %s csv; expected `%s`", csv.Spec.InstallStrategy.StrategyName, csv.GetName(), installStrategyNameDeployment))
+	}
+	if err := json.Unmarshal(csv.Spec.InstallStrategy.StrategySpecRaw, &strategy); err != nil {
+		return strategy, validator.InvalidParse(fmt.Sprintf("Error: parsing install strategy of %s csv to %T type:  %s ", csv.GetName(), strategy, err), csv.GetName())
+	}
+	return strategy, validator.Error{}
+}
+
+// csvExtensions holds the CSV fields that are newer than the vendored OLM
+// ClusterServiceVersion type, and are therefore dropped when unmarshalling a
+// CSV into it.
+type csvExtensions struct {
+	Spec csvExtensionsSpec `json:"spec"`
+}
+
+type csvExtensionsSpec struct {
+	Skips              []string            `json:"skips,omitempty"`
+	RelatedImages      []relatedImage      `json:"relatedImages,omitempty"`
+	WebhookDefinitions []webhookDefinition `json:"webhookdefinitions,omitempty"`
+}
+
+type relatedImage struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+}
+
+type webhookDefinition struct {
+	GenerateName            string   `json:"generateName"`
+	Type                    string   `json:"type"`
+	DeploymentName          string   `json:"deploymentName"`
+	ContainerPort           int32    `json:"containerPort"`
+	WebhookPath             *string  `json:"webhookPath,omitempty"`
+	SideEffects             *string  `json:"sideEffects,omitempty"`
+	AdmissionReviewVersions []string `json:"admissionReviewVersions,omitempty"`
+	ConversionCRDs          []string `json:"conversionCRDs,omitempty"`
+}
+
+func readCSVExtensions(pathCSV string) (csvExtensions, validator.Error) {
+	var extensions csvExtensions
+	rawYaml, err := ioutil.ReadFile(pathCSV)
+	if err != nil {
+		return extensions, validator.IOError(fmt.Sprintf("Error in reading %s file:   #%s ", pathCSV, err), pathCSV)
+	}
+	rawJson, err := yaml.YAMLToJSON(rawYaml)
+	if err != nil {
+		return extensions, validator.InvalidParse(fmt.Sprintf("Error in converting to JSON for %s file:   #%s ", pathCSV, err), pathCSV)
+	}
+	if err := json.Unmarshal(rawJson, &extensions); err != nil {
+		return extensions, validator.InvalidParse(fmt.Sprintf("Error parsing %s file:   #%s ", pathCSV, err), pathCSV)
+	}
+	return extensions, validator.Error{}
+}
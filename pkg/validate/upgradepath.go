@@ -0,0 +1,95 @@
+package validate
+
+import (
+	"fmt"
+
+	"github.com/blang/semver"
+)
+
+// UpgradePath is the sequence of upgrades OLM would perform in a channel,
+// starting from an installed CSV.
+type UpgradePath struct {
+	Channel string
+	From    string
+	Head    string
+	Hops    []GraphEdge
+	// DeadEnd is set if the path stops before reaching the channel head.
+	DeadEnd bool
+}
+
+// ResolveUpgradePath follows the upgrade graph from the installed CSV named
+// from towards the head of channel, the way OLM picks updates: at each step
+// it moves to a CSV of the channel that replaces, skips or covers the current
+// CSV with its `olm.skipRange`, preferring the highest version. An empty
+// channel selects the default channel.
+func ResolveUpgradePath(graph UpgradeGraph, from, channel string) (UpgradePath, error) {
+	if channel == "" {
+		channel = graph.DefaultChannel
+	}
+	var selected *GraphChannel
+	for i := range graph.Channels {
+		if graph.Channels[i].Name == channel {
+			selected = &graph.Channels[i]
+		}
+	}
+	if selected == nil {
+		return UpgradePath{}, fmt.Errorf("channel `%s` not found in package `%s`", channel, graph.Package)
+	}
+
+	if _, ok := graph.Node(from); !ok {
+		// An installed CSV may have been removed from the manifest, as long as
+		// a newer CSV still replaces or skips it.
+		known := false
+		for _, edge := range graph.Edges {
+			if edge.To == from {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return UpgradePath{}, fmt.Errorf("csv `%s` not found in package `%s`", from, graph.Package)
+		}
+	}
+
+	path := UpgradePath{Channel: selected.Name, From: from, Head: selected.Head}
+	visited := map[string]struct{}{from: {}}
+	current := from
+	for current != selected.Head {
+		next, ok := nextUpgrade(graph, *selected, current)
+		if !ok {
+			path.DeadEnd = true
+			return path, nil
+		}
+		if _, seen := visited[next.From]; seen {
+			return path, fmt.Errorf("upgrade path from `%s` loops back to `%s`", from, next.From)
+		}
+		visited[next.From] = struct{}{}
+		// Hops are reported in upgrade direction: from the installed CSV to
+		// the CSV that replaces it.
+		path.Hops = append(path.Hops, GraphEdge{From: current, To: next.From, Kind: next.Kind})
+		current = next.From
+	}
+	return path, nil
+}
+
+// nextUpgrade returns the edge of the channel member that upgrades current,
+// picking the highest version if several do.
+func nextUpgrade(graph UpgradeGraph, channel GraphChannel, current string) (GraphEdge, bool) {
+	var best GraphEdge
+	var bestVersion semver.Version
+	found := false
+	for _, edge := range graph.Edges {
+		if edge.To != current || !isStringPresent(channel.Members, edge.From) {
+			continue
+		}
+		node, _ := graph.Node(edge.From)
+		version, err := semver.Parse(node.Version)
+		if err != nil {
+			continue
+		}
+		if !found || version.GT(bestVersion) {
+			best, bestVersion, found = edge, version, true
+		}
+	}
+	return best, found
+}
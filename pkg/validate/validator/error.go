@@ -83,6 +83,46 @@ func InvalidOperation(detail string, value interface{}) Error {
 	return Error{ErrorInvalidOperation, "", value, detail}
 }
 
+func InvalidIcon(detail string, value interface{}) Error {
+	return Error{ErrorInvalidIcon, "", value, detail}
+}
+
+func InvalidMetadata(detail string, field string, value interface{}) Error {
+	return Error{ErrorInvalidMetadata, field, value, detail}
+}
+
+func InvalidAnnotation(detail string, field string, value interface{}) Error {
+	return Error{ErrorInvalidAnnotation, field, value, detail}
+}
+
+func InvalidImage(detail string, value interface{}) Error {
+	return Error{ErrorInvalidImage, "", value, detail}
+}
+
+func InvalidWebhook(detail string, value interface{}) Error {
+	return Error{ErrorInvalidWebhook, "", value, detail}
+}
+
+func UnsatisfiedRequirement(detail string, value interface{}) Error {
+	return Error{ErrorUnsatisfiedRequirement, "", value, detail}
+}
+
+func InvalidSchema(detail string, field string, value interface{}) Error {
+	return Error{ErrorInvalidSchema, field, value, detail}
+}
+
+func InvalidDependency(detail string, value interface{}) Error {
+	return Error{ErrorInvalidDependency, "", value, detail}
+}
+
+func InvalidIndex(detail string, value interface{}) Error {
+	return Error{ErrorInvalidIndex, "", value, detail}
+}
+
+func InvalidDescriptor(detail string, field string, value interface{}) Error {
+	return Error{ErrorInvalidDescriptor, field, value, detail}
+}
+
 const (
 	ErrorInvalidCSV               ErrorType = "CSVFileNotValid"
 	WarningFieldMissing           ErrorType = "OptionalFieldNotFound"
@@ -95,6 +135,16 @@ const (
 	ErrorInvalidManifestStructure ErrorType = "ManifestStructureNotValid"
 	ErrorInvalidBundle            ErrorType = "BundleNotValid"
 	ErrorInvalidDefaultChannel    ErrorType = "DefaultChannelNotValid"
+	ErrorInvalidDescriptor        ErrorType = "DescriptorNotValid"
+	ErrorInvalidIcon              ErrorType = "IconNotValid"
+	ErrorInvalidMetadata          ErrorType = "MetadataNotValid"
+	ErrorInvalidAnnotation        ErrorType = "AnnotationNotValid"
+	ErrorInvalidImage             ErrorType = "ImageReferenceNotValid"
+	ErrorInvalidWebhook           ErrorType = "WebhookNotValid"
+	ErrorUnsatisfiedRequirement   ErrorType = "RequirementNotSatisfied"
+	ErrorInvalidSchema            ErrorType = "SchemaNotValid"
+	ErrorInvalidDependency        ErrorType = "DependencyNotValid"
+	ErrorInvalidIndex             ErrorType = "IndexNotValid"
 )
 
 // String converts a ErrorType into its corresponding canonical error message.
@@ -122,6 +172,26 @@ func (t ErrorType) String() string {
 		return "Manifest bundle not valid"
 	case ErrorInvalidDefaultChannel:
 		return "Default channel not valid"
+	case ErrorInvalidDescriptor:
+		return "Descriptor not valid"
+	case ErrorInvalidIcon:
+		return "Icon not valid"
+	case ErrorInvalidMetadata:
+		return "Listing metadata not valid"
+	case ErrorInvalidAnnotation:
+		return "Annotation not valid"
+	case ErrorInvalidImage:
+		return "Image reference not valid"
+	case ErrorInvalidWebhook:
+		return "Webhook definition not valid"
+	case ErrorUnsatisfiedRequirement:
+		return "Requirement not satisfied"
+	case ErrorInvalidSchema:
+		return "Schema not valid"
+	case ErrorInvalidDependency:
+		return "Dependency or property not valid"
+	case ErrorInvalidIndex:
+		return "Index not valid"
 	default:
 		panic(fmt.Sprintf("Unrecognized validation error: %q", string(t)))
 	}
@@ -0,0 +1,93 @@
+package validate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dweepgogia/new-manifest-verification/pkg/validate/validator"
+	"github.com/operator-framework/operator-lifecycle-manager/pkg/api/apis/operators/v1alpha1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+)
+
+const (
+	validatingWebhookType = "ValidatingAdmissionWebhook"
+	mutatingWebhookType   = "MutatingAdmissionWebhook"
+	conversionWebhookType = "ConversionWebhook"
+)
+
+// allowedSideEffects lists the `sideEffects` values accepted for admission
+// webhooks by admissionregistration.k8s.io/v1.
+var allowedSideEffects = []string{"None", "NoneOnDryRun"}
+
+// validateWebhooks checks the `spec.webhookdefinitions` of the CSV against its
+// install strategy and crds, the CRDs in the bundle. Deployment names are not
+// checked if the install strategy could not be parsed, i.e. strategy is nil.
+func validateWebhooks(bundle ManifestBundle, csv v1alpha1.ClusterServiceVersion, crds map[string]v1beta1.CustomResourceDefinition, strategy *strategyDetailsDeployment, extensions csvExtensions, manifestResult validator.ManifestResult) validator.ManifestResult {
+	webhooks := extensions.Spec.WebhookDefinitions
+
+	deployments := map[string]struct{}{}
+	if strategy != nil {
+		for _, deployment := range strategy.DeploymentSpecs {
+			deployments[deployment.Name] = struct{}{}
+		}
+	}
+
+	converted := map[string]struct{}{}
+	for i, webhook := range webhooks {
+		name := webhook.GenerateName
+		if name == "" {
+			name = fmt.Sprintf("webhookdefinitions[%d]", i)
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidWebhook(fmt.Sprintf("Error: `generateName` not set for %s in %s csv", name, csv.GetName()), name))
+		}
+		if _, ok := deployments[webhook.DeploymentName]; strategy != nil && !ok {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidWebhook(fmt.Sprintf("Error: webhook `%s` in %s csv references deployment `%s`, which is not defined in the install strategy", name, csv.GetName(), webhook.DeploymentName), webhook.DeploymentName))
+		}
+		if webhook.ContainerPort < 1 || webhook.ContainerPort > 65535 {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidWebhook(fmt.Sprintf("Error: webhook `%s` in %s csv has invalid `containerPort` %d; expected a port between 1 and 65535", name, csv.GetName(), webhook.ContainerPort), webhook.ContainerPort))
+		}
+		if webhook.WebhookPath != nil && !strings.HasPrefix(*webhook.WebhookPath, "/") {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidWebhook(fmt.Sprintf("Error: webhook `%s` in %s csv has invalid `webhookPath` `%s`; the path must start with `/`", name, csv.GetName(), *webhook.WebhookPath), *webhook.WebhookPath))
+		}
+
+		switch webhook.Type {
+		case validatingWebhookType, mutatingWebhookType:
+			if len(webhook.AdmissionReviewVersions) == 0 {
+				manifestResult.Errors = append(manifestResult.Errors, validator.InvalidWebhook(fmt.Sprintf("Error: `admissionReviewVersions` not set for webhook `%s` in %s csv", name, csv.GetName()), name))
+			}
+			if webhook.SideEffects == nil {
+				manifestResult.Errors = append(manifestResult.Errors, validator.InvalidWebhook(fmt.Sprintf("Error: `sideEffects` not set for webhook `%s` in %s csv", name, csv.GetName()), name))
+			} else if !containsStrict(allowedSideEffects, *webhook.SideEffects) {
+				manifestResult.Errors = append(manifestResult.Errors, validator.InvalidWebhook(fmt.Sprintf("Error: invalid `sideEffects` `%s` for webhook `%s` in %s csv; expected one of %v", *webhook.SideEffects, name, csv.GetName(), allowedSideEffects), *webhook.SideEffects))
+			}
+		case conversionWebhookType:
+			if len(webhook.ConversionCRDs) == 0 {
+				manifestResult.Errors = append(manifestResult.Errors, validator.InvalidWebhook(fmt.Sprintf("Error: `conversionCRDs` not set for conversion webhook `%s` in %s csv", name, csv.GetName()), name))
+			}
+			for _, crdName := range webhook.ConversionCRDs {
+				if _, ok := crds[crdName]; !ok {
+					manifestResult.Errors = append(manifestResult.Errors, validator.InvalidWebhook(fmt.Sprintf("Error: conversion webhook `%s` in %s csv references crd `%s`, which is not present in bundle %s", name, csv.GetName(), crdName, bundle.Version), crdName))
+				}
+				converted[crdName] = struct{}{}
+			}
+		default:
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidWebhook(fmt.Sprintf("Error: unknown type `%s` for webhook `%s` in %s csv; expected one of %s, %s or %s", webhook.Type, name, csv.GetName(), validatingWebhookType, mutatingWebhookType, conversionWebhookType), webhook.Type))
+		}
+	}
+
+	// CRDs serving several versions need a conversion webhook unless all
+	// versions share a schema and OLM can use the `None` strategy.
+	for crdName, crd := range crds {
+		if len(crd.Spec.Versions) < 2 {
+			continue
+		}
+		if _, ok := converted[crdName]; ok {
+			continue
+		}
+		if crd.Spec.Conversion != nil && crd.Spec.Conversion.Strategy == "Webhook" {
+			manifestResult.Errors = append(manifestResult.Errors, validator.InvalidWebhook(fmt.Sprintf("Error: crd `%s` in bundle %s uses the `Webhook` conversion strategy but no conversion webhook is defined for it in %s csv", crdName, bundle.Version, csv.GetName()), crdName))
+		} else {
+			manifestResult.Warnings = append(manifestResult.Warnings, validator.InvalidWebhook(fmt.Sprintf("Warning: crd `%s` in bundle %s has %d versions but no conversion webhook is defined for it in %s csv", crdName, bundle.Version, len(crd.Spec.Versions), csv.GetName()), crdName))
+		}
+	}
+	return manifestResult
+}